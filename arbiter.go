@@ -29,6 +29,18 @@ type Arbiter struct {
 
 	stamp uint
 	state int // Arbiter state enum
+
+	// body angles when the arbiter was prestepped, used to update the bias between substeps
+	angle_a, angle_b float64
+
+	// a begin event was recorded, so an end event is due when the shapes separate
+	eventBegun bool
+	// the shapes are in each other's sensor overlaps
+	sensorOverlap bool
+	// chained handlers whose begin function was called, the only ones called after it
+	begunHandlers []*PriorityHandler
+	// a one-way shape let the shapes pass through each other before begin was called, so separate isn't due either
+	passThrough bool
 }
 
 // Init initializes and returns Arbiter
@@ -38,6 +50,10 @@ func (arbiter *Arbiter) Init(a, b *Shape) *Arbiter {
 
 	arbiter.handlerA = nil
 	arbiter.handlerB = nil
+	arbiter.eventBegun = false
+	arbiter.sensorOverlap = false
+	arbiter.begunHandlers = nil
+	arbiter.passThrough = false
 
 	arbiter.e = 0
 	arbiter.u = 0
@@ -115,6 +131,11 @@ func (arbiter *Arbiter) ApplyCachedImpulse(dt_coef float64) {
 		return
 	}
 
+	arbiter.warmStart(dt_coef)
+}
+
+// warmStart applies the accumulated impulses, even for new contacts.
+func (arbiter *Arbiter) warmStart(dt_coef float64) {
 	for i := 0; i < arbiter.count; i++ {
 		contact := arbiter.contacts[i]
 		j := arbiter.n.Rotate(Vector{contact.jnAcc, contact.jtAcc})
@@ -126,22 +147,24 @@ func (arbiter *Arbiter) ApplyImpulse() {
 	a := arbiter.body_a
 	b := arbiter.body_b
 	n := arbiter.n
-	surface_vr := arbiter.surface_vr
-	friction := arbiter.u
 
 	for i := 0; i < arbiter.count; i++ {
 		con := &arbiter.contacts[i]
+		if con.disabled {
+			continue
+		}
+
 		nMass := con.nMass
 		r1 := con.r1
 		r2 := con.r2
 
 		vb1 := a.v_bias.Add(r1.Perp().Mult(a.w_bias))
 		vb2 := b.v_bias.Add(r2.Perp().Mult(b.w_bias))
-		vr := relative_velocity(a, b, r1, r2).Add(surface_vr)
+		vr := relative_velocity(a, b, r1, r2)
 
 		vbn := vb2.Sub(vb1).Dot(n)
 		vrn := vr.Dot(n)
-		vrt := vr.Dot(n.Perp())
+		vrt := vr.Dot(n.Perp()) + con.surfaceSpeed
 
 		jbn := (con.bias - vbn) * nMass
 		jbnOld := con.jBias
@@ -150,8 +173,9 @@ func (arbiter *Arbiter) ApplyImpulse() {
 		jn := -(con.bounce + vrn) * nMass
 		jnOld := con.jnAcc
 		con.jnAcc = math.Max(jnOld+jn, 0)
+		con.jnMax = math.Max(con.jnMax, con.jnAcc)
 
-		jtMax := friction * con.jnAcc
+		jtMax := con.u * con.jnAcc
 		jt := -vrt * con.tMass
 		jtOld := con.jtAcc
 		con.jtAcc = Clamp(jtOld+jt, -jtMax, jtMax)
@@ -173,6 +197,11 @@ func (arb *Arbiter) PreStep(dt, slop, bias float64) {
 
 	for i := 0; i < arb.count; i++ {
 		con := &arb.contacts[i]
+		if con.disabled {
+			// Don't warm start disabled contacts.
+			con.jnAcc = 0
+			con.jtAcc = 0
+		}
 
 		// Calculate the mass normal and mass tangent.
 		con.nMass = 1.0 / k_scalar(a, b, con.r1, con.r2, n)
@@ -184,7 +213,51 @@ func (arb *Arbiter) PreStep(dt, slop, bias float64) {
 		con.jBias = 0.0
 
 		// Calculate the target bounce velocity.
-		con.bounce = normal_relative_velocity(a, b, con.r1, con.r2, n) * arb.e
+		vrn := normal_relative_velocity(a, b, con.r1, con.r2, n)
+		con.approach = -vrn
+		con.jnMax = 0
+		con.bounce = vrn * con.e
+		if dist > 0 && (con.e == 0 || vrn*dt+dist > 0) {
+			// Speculative contacts only stop the shapes from closing the gap, unless they will hit within the step and bounce.
+			con.bounce = dist / dt
+		}
+	}
+
+	arb.angle_a = a.a
+	arb.angle_b = b.a
+}
+
+// updateBias recalculates the target bias velocities from the bodies' current positions.
+// The substep solver calls it after moving the bodies part way through a step.
+func (arb *Arbiter) updateBias(dt, slop, bias float64) {
+	a := arb.body_a
+	b := arb.body_b
+	n := arb.n
+	bodyDelta := b.p.Sub(a.p)
+
+	// The contact offsets turn with their bodies.
+	rotA := ForAngle(a.a - arb.angle_a)
+	rotB := ForAngle(b.a - arb.angle_b)
+
+	for i := 0; i < arb.count; i++ {
+		con := &arb.contacts[i]
+
+		r1 := rotA.Rotate(con.r1)
+		r2 := rotB.Rotate(con.r2)
+		dist := r2.Sub(r1).Add(bodyDelta).Dot(n)
+		con.bias = -bias * math.Min(0, dist+slop) / dt
+		con.jBias = 0.0
+
+		// Keep speculative contacts closing no further than the remaining gap, until they will hit and bounce.
+		// Restitution only makes bounce positive for separating contacts, which it doesn't affect.
+		if con.bounce > 0 {
+			vrn := normal_relative_velocity(a, b, r1, r2, n)
+			if con.e != 0 && vrn*dt+dist <= 0 {
+				con.bounce = vrn * con.e
+			} else {
+				con.bounce = math.Max(dist, 0) / dt
+			}
+		}
 	}
 }
 
@@ -210,6 +283,8 @@ func (arb *Arbiter) Update(info *CollisionInfo, space *Space) {
 		// Cached impulses are not zeroed at init time.
 		con.jnAcc = 0
 		con.jtAcc = 0
+		con.jnMax = 0
+		con.approach = -normal_relative_velocity(a.body, b.body, con.r1, con.r2, info.n)
 
 		for j := 0; j < arb.count; j++ {
 			old := arb.contacts[j]
@@ -233,8 +308,28 @@ func (arb *Arbiter) Update(info *CollisionInfo, space *Space) {
 	surfaceVr := b.surfaceV.Sub(a.surfaceV)
 	arb.surface_vr = surfaceVr.Sub(info.n.Mult(surfaceVr.Dot(info.n)))
 
-	typeA := info.a.collisionType
-	typeB := info.b.collisionType
+	// Start the contacts with the arbiter's values for pre-solve callbacks to modify.
+	for i := 0; i < arb.count; i++ {
+		con := &arb.contacts[i]
+		con.e = arb.e
+		con.u = arb.u
+		con.surfaceSpeed = arb.surface_vr.Dot(arb.n.Perp())
+		con.disabled = false
+	}
+
+	arb.lookupHandlers(space)
+
+	// mark it as new if it's been cached
+	if arb.state == CP_ARBITER_STATE_CACHED {
+		arb.state = CP_ARBITER_STATE_FIRST_COLLISION
+		arb.passThrough = false
+	}
+}
+
+// lookupHandlers finds the collision handlers for the arbiter's shapes.
+func (arb *Arbiter) lookupHandlers(space *Space) {
+	typeA := arb.a.collisionType
+	typeB := arb.b.collisionType
 	handler := space.LookupHandler(typeA, typeB, space.defaultHandler)
 	arb.handler = handler
 
@@ -252,11 +347,6 @@ func (arb *Arbiter) Update(info *CollisionInfo, space *Space) {
 			arb.handlerB = space.LookupHandler(typeB, WILDCARD_COLLISION_TYPE, &CollisionHandlerDoNothing)
 		}
 	}
-
-	// mark it as new if it's been cached
-	if arb.state == CP_ARBITER_STATE_CACHED {
-		arb.state = CP_ARBITER_STATE_FIRST_COLLISION
-	}
 }
 
 // Ignore marks a collision pair to be ignored until the two objects separate.
@@ -421,6 +511,48 @@ func (arb *Arbiter) TotalImpulse() Vector {
 	return sum.Neg()
 }
 
+// TotalKE calculates the amount of energy lost in a collision including static, but not dynamic friction.
+//
+// This function should only be called from a post-solve, post-step or EachArbiter callback.
+func (arb *Arbiter) TotalKE() float64 {
+	sum := 0.0
+
+	count := arb.Count()
+	for i := 0; i < count; i++ {
+		con := arb.contacts[i]
+		eCoef := (1 - con.e) / (1 + con.e)
+		jnAcc := con.jnAcc
+		jtAcc := con.jtAcc
+
+		sum += eCoef*jnAcc*jnAcc/con.nMass + jtAcc*jtAcc/con.tMass
+	}
+
+	return sum
+}
+
+// ApproachSpeed returns the speed the shapes approached each other at along the normal at contact i, before the solver ran.
+// It is negative if they were moving apart. It is available from the pre-solve callback on.
+func (arb *Arbiter) ApproachSpeed(i int) float64 {
+	assert(0 <= i && i < arb.Count(), "Index error: The specified contact index is invalid for this arbiter")
+	return arb.contacts[i].approach
+}
+
+// MaxNormalImpulse returns the largest normal impulse the solver applied at contact i during the step.
+// It is non-zero for speculative contacts that stopped the shapes even when the final impulse is zero,
+// and covers all the substeps of SolverSubstep.
+//
+// This function should only be called from a post-solve, post-step or EachArbiter callback.
+func (arb *Arbiter) MaxNormalImpulse(i int) float64 {
+	assert(0 <= i && i < arb.Count(), "Index error: The specified contact index is invalid for this arbiter")
+	return arb.contacts[i].jnMax
+}
+
+// contactPoint returns the point halfway between the surfaces of the shapes at contact i.
+func (arb *Arbiter) contactPoint(i int) Vector {
+	con := arb.contacts[i]
+	return arb.body_a.p.Add(con.r1).Lerp(arb.body_b.p.Add(con.r2), 0.5)
+}
+
 func (arb *Arbiter) Count() int {
 	if arb.state < CP_ARBITER_STATE_CACHED {
 		return int(arb.count)
@@ -453,6 +585,106 @@ func (arb *Arbiter) Normal() Vector {
 	}
 }
 
+// Restitution returns the restitution of the collision, the product of the shapes' elasticities unless it was changed.
+func (arb *Arbiter) Restitution() float64 {
+	return arb.e
+}
+
+// SetRestitution overrides the restitution of the collision and each of its contacts.
+// It is recalculated every step, so call it from a pre-solve callback.
+func (arb *Arbiter) SetRestitution(e float64) {
+	arb.e = e
+	for i := 0; i < arb.count; i++ {
+		arb.contacts[i].e = e
+	}
+}
+
+// Friction returns the friction of the collision, the product of the shapes' friction unless it was changed.
+func (arb *Arbiter) Friction() float64 {
+	return arb.u
+}
+
+// SetFriction overrides the friction of the collision and each of its contacts.
+// It is recalculated every step, so call it from a pre-solve callback.
+func (arb *Arbiter) SetFriction(u float64) {
+	arb.u = u
+	for i := 0; i < arb.count; i++ {
+		arb.contacts[i].u = u
+	}
+}
+
+// SurfaceVelocity returns the velocity of ShapeB's surface relative to ShapeA's, which friction drags the shapes towards.
+func (arb *Arbiter) SurfaceVelocity() Vector {
+	if arb.swapped {
+		return arb.surface_vr.Neg()
+	}
+	return arb.surface_vr
+}
+
+// SetSurfaceVelocity overrides the surface velocity of the collision and the tangent speed of each of its contacts.
+// Only the part along the surface is used. It is recalculated every step, so call it from a pre-solve callback.
+func (arb *Arbiter) SetSurfaceVelocity(vr Vector) {
+	if arb.swapped {
+		vr = vr.Neg()
+	}
+	arb.surface_vr = vr.Sub(arb.n.Mult(vr.Dot(arb.n)))
+
+	for i := 0; i < arb.count; i++ {
+		arb.contacts[i].surfaceSpeed = arb.surface_vr.Dot(arb.n.Perp())
+	}
+}
+
+// ContactRestitution returns the restitution of contact i.
+func (arb *Arbiter) ContactRestitution(i int) float64 {
+	assert(0 <= i && i < arb.Count(), "Index error: The specified contact index is invalid for this arbiter")
+	return arb.contacts[i].e
+}
+
+// SetContactRestitution overrides the restitution of contact i for the current step. Call it from a pre-solve callback.
+func (arb *Arbiter) SetContactRestitution(i int, e float64) {
+	assert(0 <= i && i < arb.Count(), "Index error: The specified contact index is invalid for this arbiter")
+	arb.contacts[i].e = e
+}
+
+// ContactFriction returns the friction of contact i.
+func (arb *Arbiter) ContactFriction(i int) float64 {
+	assert(0 <= i && i < arb.Count(), "Index error: The specified contact index is invalid for this arbiter")
+	return arb.contacts[i].u
+}
+
+// SetContactFriction overrides the friction of contact i for the current step. Call it from a pre-solve callback.
+// Setting it depending on the direction the shapes slide in gives anisotropic friction.
+func (arb *Arbiter) SetContactFriction(i int, u float64) {
+	assert(0 <= i && i < arb.Count(), "Index error: The specified contact index is invalid for this arbiter")
+	arb.contacts[i].u = u
+}
+
+// ContactTangentSpeed returns the speed of ShapeB's surface relative to ShapeA's at contact i, along Normal().Perp().
+func (arb *Arbiter) ContactTangentSpeed(i int) float64 {
+	assert(0 <= i && i < arb.Count(), "Index error: The specified contact index is invalid for this arbiter")
+	return arb.contacts[i].surfaceSpeed
+}
+
+// SetContactTangentSpeed overrides the tangent speed of contact i for the current step. Call it from a pre-solve callback.
+// Friction drags ShapeA along Normal().Perp() at that speed relative to ShapeB, like a box on a conveyor belt.
+func (arb *Arbiter) SetContactTangentSpeed(i int, speed float64) {
+	assert(0 <= i && i < arb.Count(), "Index error: The specified contact index is invalid for this arbiter")
+	arb.contacts[i].surfaceSpeed = speed
+}
+
+// ContactEnabled returns false if contact i was disabled with SetContactEnabled.
+func (arb *Arbiter) ContactEnabled(i int) bool {
+	assert(0 <= i && i < arb.Count(), "Index error: The specified contact index is invalid for this arbiter")
+	return !arb.contacts[i].disabled
+}
+
+// SetContactEnabled enables or disables contact i for the current step. Call it from a pre-solve callback.
+// The solver applies no impulses at disabled contacts, so the shapes pass through each other there.
+func (arb *Arbiter) SetContactEnabled(i int, enabled bool) {
+	assert(0 <= i && i < arb.Count(), "Index error: The specified contact index is invalid for this arbiter")
+	arb.contacts[i].disabled = !enabled
+}
+
 // ContactPointSet wraps up the important collision data for an arbiter.
 type ContactPointSet struct {
 	// Count is the number of contact points in the set.
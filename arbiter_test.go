@@ -1,9 +1,173 @@
 package cp
 
 import (
+	"math"
 	"testing"
 )
 
 func TestStuff(t *testing.T) {
 	t.Log("hi")
 }
+
+func TestArbiter_ImpactInfo(t *testing.T) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	space.AddShape(NewSegment(space.StaticBody, Vector{-100, 0}, Vector{100, 0}, 0))
+
+	// The ball lands at about 95 units per second.
+	ball := space.AddBody(NewBody(1, MomentForCircle(1, 0, 5, Vector{})))
+	ball.SetPosition(Vector{0, 50})
+	space.AddShape(NewCircle(ball, 5, Vector{}))
+
+	var approaches, impulses, energies []float64
+	handler := space.NewCollisionHandler(0, 0)
+	handler.PreSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) bool {
+		approaches = append(approaches, arb.ApproachSpeed(0))
+		return true
+	}
+	handler.PostSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) {
+		impulses = append(impulses, arb.MaxNormalImpulse(0))
+		energies = append(energies, arb.TotalKE())
+	}
+
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	if len(approaches) == 0 || approaches[0] < 90 || approaches[0] > 100 {
+		t.Fatalf("expected the first approach speed to be about 95, got %v", approaches)
+	}
+	if impulses[0] < 90 || impulses[0] > 100 {
+		t.Errorf("expected the landing to stop the ball with an impulse of about 95, got %v", impulses[0])
+	}
+	if energies[0] < 100*energies[len(energies)-1] {
+		t.Errorf("expected the landing to lose much more energy than resting, got %v and %v", energies[0], energies[len(energies)-1])
+	}
+
+	// Resting on the ground only takes the ball's weight.
+	last := impulses[len(impulses)-1]
+	if math.Abs(last-100.0/60.0) > 0.1 {
+		t.Errorf("expected a resting impulse of about 1.67, got %v", last)
+	}
+	if a := approaches[len(approaches)-1]; math.Abs(a) > 2 {
+		t.Errorf("expected a small approach speed while resting, got %v", a)
+	}
+}
+
+func newModifiedBoxSpace(preSolve CollisionPreSolveFunc) (*Space, *Body) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	space.AddShape(NewSegment(space.StaticBody, Vector{-1000, 0}, Vector{1000, 0}, 0)).SetFriction(1)
+
+	box := space.AddBody(NewBody(1, MomentForBox(1, 10, 10)))
+	box.SetPosition(Vector{0, 5})
+	space.AddShape(NewBox(box, 10, 10, 0)).SetFriction(1)
+
+	space.NewCollisionHandler(0, 0).PreSolveFunc = preSolve
+	return space, box
+}
+
+func TestArbiter_ContactTangentSpeed(t *testing.T) {
+	space, box := newModifiedBoxSpace(func(arb *Arbiter, space *Space, userData interface{}) bool {
+		// Move the ground's surface to the right under the box.
+		speed := 20 * arb.Normal().Perp().X
+		if a, _ := arb.Bodies(); a.GetType() == BODY_STATIC {
+			speed = -speed
+		}
+		for i := 0; i < arb.Count(); i++ {
+			arb.SetContactTangentSpeed(i, speed)
+		}
+		return true
+	})
+
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if v := box.Velocity(); math.Abs(v.X-20) > 0.1 {
+		t.Errorf("expected the box to ride the conveyor at 20, got %v", v)
+	}
+}
+
+func TestArbiter_ContactFriction(t *testing.T) {
+	// Ice that is only slippery to the right. The ground doesn't move, so the bodies' velocities add up to the box's.
+	space, box := newModifiedBoxSpace(func(arb *Arbiter, space *Space, userData interface{}) bool {
+		if a, b := arb.Bodies(); a.Velocity().X+b.Velocity().X > 0 {
+			for i := 0; i < arb.Count(); i++ {
+				arb.SetContactFriction(i, 0)
+			}
+		}
+		return true
+	})
+
+	box.SetVelocity(10, 0)
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if v := box.Velocity(); math.Abs(v.X-10) > 1e-6 {
+		t.Errorf("expected the box to slide right without friction, got %v", v)
+	}
+
+	box.SetVelocity(-10, 0)
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if v := box.Velocity(); math.Abs(v.X) > 0.1 {
+		t.Errorf("expected friction to stop the box sliding left, got %v", v)
+	}
+}
+
+func TestArbiter_ContactRestitution(t *testing.T) {
+	space, box := newModifiedBoxSpace(func(arb *Arbiter, space *Space, userData interface{}) bool {
+		for i := 0; i < arb.Count(); i++ {
+			arb.SetContactRestitution(i, 1)
+		}
+		return true
+	})
+	box.SetPosition(Vector{0, 50})
+
+	bounced := false
+	for i := 0; i < 60 && !bounced; i++ {
+		space.Step(1.0 / 60.0)
+		bounced = box.Velocity().Y > 80
+	}
+	if !bounced {
+		t.Error("expected the box to bounce off the ground")
+	}
+}
+
+func TestArbiter_ContactEnabled(t *testing.T) {
+	space, box := newModifiedBoxSpace(func(arb *Arbiter, space *Space, userData interface{}) bool {
+		for i := 0; i < arb.Count(); i++ {
+			arb.SetContactEnabled(i, false)
+			if arb.ContactEnabled(i) {
+				t.Error("expected the contact to be disabled")
+			}
+		}
+		return true
+	})
+
+	for i := 0; i < 30; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if p := box.Position(); p.Y > -5 {
+		t.Errorf("expected the box to fall through the ground, got %v", p)
+	}
+}
+
+func TestArbiter_SetFriction(t *testing.T) {
+	space, box := newModifiedBoxSpace(func(arb *Arbiter, space *Space, userData interface{}) bool {
+		arb.SetFriction(0)
+		if arb.Friction() != 0 || arb.ContactFriction(0) != 0 {
+			t.Error("expected the friction of the arbiter and its contacts to change")
+		}
+		return true
+	})
+
+	box.SetVelocity(10, 0)
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if v := box.Velocity(); math.Abs(v.X-10) > 1e-6 {
+		t.Errorf("expected the box to slide without friction, got %v", v)
+	}
+}
@@ -1,6 +1,9 @@
 package cp
 
-import "math"
+import (
+	"math"
+	"sort"
+)
 
 type BBTreeVelocityFunc func(obj interface{}) Vector
 
@@ -316,7 +319,7 @@ func (tree *BBTree) Remove(obj *Shape, hashId HashValue) {
 }
 
 func (tree *BBTree) Reindex() {
-	panic("implement me")
+	tree.ReindexQuery(VoidQueryFunc, nil)
 }
 
 func (tree *BBTree) ReindexObject(obj *Shape, hashId HashValue) {
@@ -340,10 +343,7 @@ func (tree *BBTree) ReindexQuery(f SpatialIndexQuery, data interface{}) {
 	})
 
 	staticIndex := tree.spatialIndex.staticIndex
-	var staticRoot *Node
-	if staticIndex != nil {
-		staticRoot = staticIndex.class.(*BBTree).root
-	}
+	staticRoot := staticIndex.GetRootIfTree()
 
 	context := &MarkContext{tree, staticRoot, f, data}
 	tree.root.MarkSubtree(context)
@@ -451,6 +451,162 @@ func (tree *BBTree) SegmentQuery(obj interface{}, a, b Vector, t_exit float64, f
 	}
 }
 
+// Optimize rebuilds the tree from the top down by recursively splitting the leaves at the median of their longest axis.
+//
+// Inserting leaves one at a time can leave the tree poorly balanced, particularly with large amounts of static geometry.
+// Call it after adding many objects at once.
+func (tree *BBTree) Optimize() {
+	if tree.Count() == 0 {
+		return
+	}
+
+	nodes := make([]*Node, 0, tree.Count())
+	tree.leaves.Each(func(leaf *Node) {
+		nodes = append(nodes, leaf)
+	})
+
+	if tree.root != nil {
+		tree.SubtreeRecycle(tree.root)
+	}
+	tree.root = tree.partitionNodes(nodes)
+	tree.root.parent = nil
+}
+
+// InsertBatch inserts objects without inserting their leaves one at a time, and then builds the tree from all of its leaves like Optimize.
+func (tree *BBTree) InsertBatch(objs []*Shape) {
+	if len(objs) == 0 {
+		return
+	}
+
+	stamp := tree.GetMasterTree().stamp
+	leaves := make([]*Node, 0, len(objs))
+	for _, obj := range objs {
+		leaf := tree.leaves.Insert(obj.HashId(), obj, func(obj *Shape) *Node {
+			return tree.NewLeaf(obj)
+		})
+		leaf.stamp = stamp
+		leaves = append(leaves, leaf)
+	}
+
+	tree.Optimize()
+
+	// The new leaves share a stamp, so each pair between them is only added once.
+	for _, leaf := range leaves {
+		tree.LeafAddPairs(leaf)
+	}
+	tree.IncrementStamp()
+}
+
+func (tree *BBTree) SubtreeRecycle(node *Node) {
+	if !node.IsLeaf() {
+		tree.SubtreeRecycle(node.a)
+		tree.SubtreeRecycle(node.b)
+		tree.RecycleNode(node)
+	}
+}
+
+func (tree *BBTree) partitionNodes(nodes []*Node) *Node {
+	count := len(nodes)
+	if count == 1 {
+		return nodes[0]
+	} else if count == 2 {
+		return tree.NewNode(nodes[0], nodes[1])
+	}
+
+	// Find the AABB for these nodes
+	bb := nodes[0].bb
+	for _, node := range nodes[1:] {
+		bb = bb.Merge(node.bb)
+	}
+
+	// Split it on it's longest axis
+	splitWidth := bb.R-bb.L > bb.T-bb.B
+
+	// Sort the bounds and use the median as the splitting point
+	bounds := make([]float64, count*2)
+	for i, node := range nodes {
+		if splitWidth {
+			bounds[2*i+0] = node.bb.L
+			bounds[2*i+1] = node.bb.R
+		} else {
+			bounds[2*i+0] = node.bb.B
+			bounds[2*i+1] = node.bb.T
+		}
+	}
+	sort.Float64s(bounds)
+	split := (bounds[count-1] + bounds[count]) * 0.5
+
+	// Partition the nodes by which side of the split their centers fall on.
+	// Comparing centers rather than merged areas keeps working for flat bounding boxes.
+	right := count
+	for left := 0; left < right; {
+		node := nodes[left]
+		var center float64
+		if splitWidth {
+			center = (node.bb.L + node.bb.R) * 0.5
+		} else {
+			center = (node.bb.B + node.bb.T) * 0.5
+		}
+
+		if center > split {
+			right--
+			nodes[left] = nodes[right]
+			nodes[right] = node
+		} else {
+			left++
+		}
+	}
+
+	if right == count || right == 0 {
+		// Every center landed on the same side, so just split the list in half.
+		right = count / 2
+	}
+
+	// Recurse and build the node!
+	return tree.NewNode(tree.partitionNodes(nodes[:right]), tree.partitionNodes(nodes[right:]))
+}
+
+// BBTreeStats describes the quality of a BBTree.
+type BBTreeStats struct {
+	// Leaves is the number of objects in the tree.
+	Leaves int
+	// MaxDepth is the depth of the deepest leaf. A tree with a single leaf has a depth of 0.
+	MaxDepth int
+	// AverageDepth is the mean depth of the leaves.
+	AverageDepth float64
+	// TotalArea is the sum of the bounding box areas of the internal nodes. Smaller is better for queries.
+	TotalArea float64
+}
+
+// Stats calculates metrics describing how well balanced the tree is.
+func (tree *BBTree) Stats() BBTreeStats {
+	var stats BBTreeStats
+	if tree.root == nil {
+		return stats
+	}
+
+	var depthSum int
+	var visit func(node *Node, depth int)
+	visit = func(node *Node, depth int) {
+		if node.IsLeaf() {
+			stats.Leaves++
+			depthSum += depth
+			if depth > stats.MaxDepth {
+				stats.MaxDepth = depth
+			}
+			return
+		}
+
+		stats.TotalArea += node.bb.Area()
+		visit(node.a, depth+1)
+		visit(node.b, depth+1)
+	}
+	visit(tree.root, 0)
+
+	stats.AverageDepth = float64(depthSum) / float64(stats.Leaves)
+	return stats
+}
+
 func (tree *BBTree) GetBB(obj *Shape) BB {
 	bb := tree.spatialIndex.bbfunc(obj)
 	if tree.velocityFunc != nil {
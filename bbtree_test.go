@@ -29,3 +29,108 @@ func TestBBTree_GetMasterTree(t *testing.T) {
 	}
 
 }
+
+func TestBBTree_Optimize(t *testing.T) {
+	space := NewSpace()
+
+	var shapes []*Shape
+	for i := 0; i < 2000; i++ {
+		x := float64(i) * 10
+		shapes = append(shapes, NewSegment(space.StaticBody, Vector{x, 0}, Vector{x + 10, float64(i % 7)}, 1))
+	}
+	for _, shape := range shapes {
+		space.AddShape(shape)
+	}
+
+	tree := space.StaticIndex().GetTree()
+	before := tree.Stats()
+	if before.Leaves != len(shapes) {
+		t.Fatalf("expected %v leaves, got %v", len(shapes), before.Leaves)
+	}
+
+	queryCount := func() int {
+		count := 0
+		space.BBQuery(NewBB(5000, -1, 6000, 10), SHAPE_FILTER_ALL, func(shape *Shape, data interface{}) {
+			count++
+		}, nil)
+		return count
+	}
+	expected := queryCount()
+
+	tree.Optimize()
+	after := tree.Stats()
+
+	if after.Leaves != before.Leaves {
+		t.Errorf("expected %v leaves after optimizing, got %v", before.Leaves, after.Leaves)
+	}
+	if after.MaxDepth > 16 || after.MaxDepth > before.MaxDepth {
+		t.Errorf("expected a balanced tree, depth went from %v to %v", before.MaxDepth, after.MaxDepth)
+	}
+	if after.TotalArea > before.TotalArea {
+		t.Errorf("expected total area to shrink, went from %v to %v", before.TotalArea, after.TotalArea)
+	}
+	if tree.root.parent != nil {
+		t.Error("root has a parent")
+	}
+	if count := queryCount(); count != expected {
+		t.Errorf("expected query to find %v shapes, found %v", expected, count)
+	}
+}
+
+func TestSpace_AddShapes(t *testing.T) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+
+	var shapes []*Shape
+	for i := 0; i < 500; i++ {
+		x := float64(i) * 4
+		shapes = append(shapes, NewSegment(space.StaticBody, Vector{x, 0}, Vector{x + 4, 0}, 0))
+	}
+	space.AddShapes(shapes)
+
+	if stats := space.StaticIndex().GetTree().Stats(); stats.MaxDepth > 10 {
+		t.Errorf("expected a balanced tree, got depth %v", stats.MaxDepth)
+	}
+
+	body := space.AddBody(NewBody(1, MomentForBox(1, 2, 2)))
+	body.SetPosition(Vector{1000, 5})
+	space.AddShape(NewBox(body, 2, 2, 0))
+
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	if body.Position().Y < 0 {
+		t.Errorf("body fell through the ground: %v", body.Position())
+	}
+}
+
+func TestSpace_AddShapesPairs(t *testing.T) {
+	space := NewSpace()
+
+	// The batch adds a static shape under a body at rest, and bodies that overlap each other.
+	resting := space.AddBody(NewBody(1, MomentForBox(1, 2, 2)))
+	space.AddShape(NewBox(resting, 2, 2, 0))
+	space.Step(1.0 / 60.0)
+
+	shapes := []*Shape{NewBox2(space.StaticBody, NewBB(-2, -3, 2, -0.5), 0)}
+	var bodies []*Body
+	for i := 0; i < 10; i++ {
+		body := space.AddBody(NewBody(1, MomentForBox(1, 2, 2)))
+		body.SetPosition(Vector{20, float64(i)})
+		shapes = append(shapes, NewBox(body, 2, 2, 0))
+		bodies = append(bodies, body)
+	}
+	space.AddShapes(shapes)
+
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	if y := resting.Position().Y; y < 0.3 {
+		t.Errorf("expected the body to be pushed out of the static shape, got y=%v", y)
+	}
+	if d := bodies[1].Position().Distance(bodies[0].Position()); d < 1.8 {
+		t.Errorf("expected the overlapping bodies to be pushed apart, got a distance of %v", d)
+	}
+}
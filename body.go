@@ -20,6 +20,9 @@ type BodyVelocityFunc func(body *Body, gravity Vector, damping float64, dt float
 // BodyPositionFunc is rigid body position update function type.
 type BodyPositionFunc func(body *Body, dt float64)
 
+// BodySpeedClampFunc is called when a body's velocity is clamped to its speed limits, with the velocities it had before.
+type BodySpeedClampFunc func(body *Body, v Vector, w float64)
+
 type Body struct {
 	// UserData is an object that this constraint is associated with.
 	//
@@ -46,6 +49,18 @@ type Body struct {
 	w float64 // Angular velocity,
 	t float64 // Torque (radians)
 
+	gravityScale float64 // Multiplier for the gravity applied to the body
+
+	// Fraction of velocity the body keeps after one second, on top of the space's damping.
+	linearDamping  float64
+	angularDamping float64
+
+	// Speed limits, 0 to use the space's limits.
+	maxLinearSpeed  float64
+	maxAngularSpeed float64
+	// Space stamp of the step the speed clamp function was last called in.
+	clampStamp uint
+
 	transform Transform
 
 	// "pseudo-velocities" used for eliminating overlap.
@@ -62,6 +77,16 @@ type Body struct {
 	sleepingRoot     *Body
 	sleepingNext     *Body
 	sleepingIdleTime float64
+
+	// Sleep settings, thresholds of 0 use the space's thresholds.
+	allowSleep         bool
+	idleSpeedThreshold float64
+	sleepTimeThreshold float64
+
+	// Transform before the last step, recorded by a Stepper for interpolation.
+	prevP     Vector
+	prevA     float64
+	prevStamp uint
 }
 
 // String returns body id as string
@@ -74,15 +99,19 @@ func (b Body) String() string {
 // Guessing the moment of inertia is usually a bad idea. Use the moment estimation functions MomentFor*().
 func NewBody(mass, moment float64) *Body {
 	body := &Body{
-		id:            bodyCur,
-		cog:           Vector{},
-		p:             Vector{},
-		v:             Vector{},
-		f:             Vector{},
-		v_bias:        Vector{},
-		transform:     NewTransformIdentity(),
-		velocity_func: BodyUpdateVelocity,
-		position_func: BodyUpdatePosition,
+		id:             bodyCur,
+		cog:            Vector{},
+		p:              Vector{},
+		v:              Vector{},
+		f:              Vector{},
+		v_bias:         Vector{},
+		transform:      NewTransformIdentity(),
+		gravityScale:   1,
+		linearDamping:  1,
+		angularDamping: 1,
+		allowSleep:     true,
+		velocity_func:  BodyUpdateVelocity,
+		position_func:  BodyUpdatePosition,
 	}
 	bodyCur++
 
@@ -139,10 +168,50 @@ func (body *Body) SetMass(mass float64) {
 }
 
 // IdleTime returns sleeping idle time of the body
+// For sleeping bodies, it is the idle time when they fell asleep.
 func (body *Body) IdleTime() float64 {
 	return body.sleepingIdleTime
 }
 
+// AllowSleep returns false if the body and the bodies touching it are kept awake.
+func (body *Body) AllowSleep() bool {
+	return body.allowSleep
+}
+
+// SetAllowSleep sets whether the body can fall asleep. It defaults to true.
+// Bodies that can't sleep, such as the player, also keep the bodies they touch awake. Disallowing sleep wakes the body.
+func (body *Body) SetAllowSleep(allowSleep bool) {
+	body.allowSleep = allowSleep
+	if !allowSleep {
+		body.Activate()
+	}
+}
+
+// IdleSpeedThreshold returns the body's idle speed threshold, or 0 if it uses the space's threshold.
+func (body *Body) IdleSpeedThreshold() float64 {
+	return body.idleSpeedThreshold
+}
+
+// SetIdleSpeedThreshold sets the speed below which the body is considered idle, overriding the space's IdleSpeedThreshold.
+// Use 0 for the space's threshold.
+func (body *Body) SetIdleSpeedThreshold(speed float64) {
+	assert(speed >= 0, "Idle speed threshold must not be negative.")
+	body.idleSpeedThreshold = speed
+}
+
+// SleepTimeThreshold returns the body's sleep time threshold, or 0 if it uses the space's threshold.
+func (body *Body) SleepTimeThreshold() float64 {
+	return body.sleepTimeThreshold
+}
+
+// SetSleepTimeThreshold sets how long the body must be idle before it can fall asleep, overriding the space's SleepTimeThreshold.
+// Use 0 for the space's threshold. Sleeping must still be enabled on the space by setting its SleepTimeThreshold.
+// A component only falls asleep once every body in it has been idle for its threshold.
+func (body *Body) SetSleepTimeThreshold(threshold float64) {
+	assert(threshold >= 0, "Sleep time threshold must not be negative.")
+	body.sleepTimeThreshold = threshold
+}
+
 // SetType sets the type of the body.
 func (body *Body) SetType(newType int) {
 	oldType := body.GetType()
@@ -312,6 +381,121 @@ func (body *Body) SetVelocityVector(v Vector) {
 	body.v = v
 }
 
+// GravityScale returns the multiplier for the gravity applied to the body.
+func (body *Body) GravityScale() float64 {
+	return body.gravityScale
+}
+
+// SetGravityScale sets the multiplier for the gravity applied to the body, including gravity fields. It defaults to 1.
+// Use 0 for bodies that float, or a negative scale for bodies that fall upwards.
+func (body *Body) SetGravityScale(scale float64) {
+	body.Activate()
+	body.gravityScale = scale
+}
+
+// Gravity returns the gravity acting on the body, given the space's gravity.
+// It adds the gravity of the space's gravity fields and applies the body's gravity scale.
+func (body *Body) Gravity(gravity Vector) Vector {
+	if body.space != nil {
+		for _, field := range body.space.gravityFields {
+			gravity = gravity.Add(field.Gravity(body))
+		}
+	}
+	return gravity.Mult(body.gravityScale)
+}
+
+// LinearDamping returns the fraction of linear velocity the body keeps after one second.
+func (body *Body) LinearDamping() float64 {
+	return body.linearDamping
+}
+
+// SetLinearDamping sets the fraction of linear velocity the body keeps after one second, on top of the space's damping.
+// It defaults to 1, meaning no extra damping. A value of 0.5 means the body loses half of its speed each second, like a balloon in air.
+func (body *Body) SetLinearDamping(damping float64) {
+	assert(damping >= 0, "Damping must not be negative.")
+	body.Activate()
+	body.linearDamping = damping
+}
+
+// AngularDamping returns the fraction of angular velocity the body keeps after one second.
+func (body *Body) AngularDamping() float64 {
+	return body.angularDamping
+}
+
+// SetAngularDamping sets the fraction of angular velocity the body keeps after one second, on top of the space's damping.
+// It defaults to 1, meaning no extra damping.
+func (body *Body) SetAngularDamping(damping float64) {
+	assert(damping >= 0, "Damping must not be negative.")
+	body.Activate()
+	body.angularDamping = damping
+}
+
+// Damping returns the linear and angular damping multipliers for a step, given the space's damping for the step.
+func (body *Body) Damping(damping, dt float64) (linear, angular float64) {
+	linear, angular = damping, damping
+	if body.linearDamping != 1 {
+		linear *= math.Pow(body.linearDamping, dt)
+	}
+	if body.angularDamping != 1 {
+		angular *= math.Pow(body.angularDamping, dt)
+	}
+	return linear, angular
+}
+
+// MaxLinearSpeed returns the body's speed limit, or 0 if it uses the space's limit.
+func (body *Body) MaxLinearSpeed() float64 {
+	return body.maxLinearSpeed
+}
+
+// SetMaxLinearSpeed limits the body's speed, overriding the space's limit. Use 0 for the space's limit, or INFINITY for no limit.
+func (body *Body) SetMaxLinearSpeed(speed float64) {
+	assert(speed >= 0, "Speed limit must not be negative.")
+	body.maxLinearSpeed = speed
+}
+
+// MaxAngularSpeed returns the body's angular speed limit, or 0 if it uses the space's limit.
+func (body *Body) MaxAngularSpeed() float64 {
+	return body.maxAngularSpeed
+}
+
+// SetMaxAngularSpeed limits the body's angular speed, overriding the space's limit. Use 0 for the space's limit, or INFINITY for no limit.
+func (body *Body) SetMaxAngularSpeed(speed float64) {
+	assert(speed >= 0, "Speed limit must not be negative.")
+	body.maxAngularSpeed = speed
+}
+
+// ClampVelocity clamps the body's velocity and angular velocity to its speed limits.
+// If either was clamped, the space's speed clamp function is called, at most once per step for each body.
+// The default update functions call it for dynamic bodies.
+func (body *Body) ClampVelocity() {
+	maxV, maxW := body.maxLinearSpeed, body.maxAngularSpeed
+	space := body.space
+	if space != nil {
+		if maxV == 0 {
+			maxV = space.maxLinearSpeed
+		}
+		if maxW == 0 {
+			maxW = space.maxAngularSpeed
+		}
+	}
+
+	v, w := body.v, body.w
+	clamped := false
+	if maxV > 0 && v.LengthSq() > maxV*maxV {
+		body.v = v.Mult(maxV / v.Length())
+		clamped = true
+	}
+	if maxW > 0 && math.Abs(w) > maxW {
+		body.w = math.Copysign(maxW, w)
+		clamped = true
+	}
+
+	if clamped && space != nil && space.speedClampFunc != nil && body.clampStamp != space.stamp {
+		body.clampStamp = space.stamp
+		space.speedClampFunc(body, v, w)
+	}
+}
+
 // UpdateVelocity is the default velocity integration function.
 func (body *Body) UpdateVelocity(gravity Vector, damping, dt float64) {
 	if body.GetType() == BODY_KINEMATIC {
@@ -320,8 +504,12 @@ func (body *Body) UpdateVelocity(gravity Vector, damping, dt float64) {
 
 	assert(body.m > 0 && body.i > 0, "Body's mass and moment must be positive")
 
-	body.v = body.v.Mult(damping).Add(gravity.Add(body.f.Mult(body.m_inv)).Mult(dt))
-	body.w = body.w*damping + body.t*body.i_inv*dt
+	gravity = body.Gravity(gravity)
+
+	linear, angular := body.Damping(damping, dt)
+	body.v = body.v.Mult(linear).Add(gravity.Add(body.f.Mult(body.m_inv)).Mult(dt))
+	body.w = body.w*angular + body.t*body.i_inv*dt
+	body.ClampVelocity()
 
 	body.f = Vector{}
 	body.t = 0
@@ -362,15 +550,34 @@ func (body *Body) SetAngularVelocity(angularVelocity float64) {
 
 // SetTransform sets transform
 func (body *Body) SetTransform(p Vector, a float64) {
+	body.transform = body.transformAt(p, a)
+}
+
+func (body *Body) transformAt(p Vector, a float64) Transform {
 	rot := Vector{math.Cos(a), math.Sin(a)}
 	c := body.cog
 
-	body.transform = NewTransformTranspose(
+	return NewTransformTranspose(
 		rot.X, -rot.Y, p.X-(c.X*rot.X-c.Y*rot.Y),
 		rot.Y, rot.X, p.Y-(c.X*rot.Y+c.Y*rot.X),
 	)
 }
 
+// InterpolatedTransform returns the body's transform blended between the previous step and the current one.
+//
+// An alpha of 0 gives the transform before the last step, and 1 gives the current transform.
+// Use it with Stepper.Alpha() to render smoothly when the frame rate doesn't match the time step.
+// Bodies that didn't move in the last Stepper step, such as sleeping or newly added bodies, return their current transform.
+func (body *Body) InterpolatedTransform(alpha float64) Transform {
+	if body.space == nil || body.prevStamp == 0 || body.prevStamp != body.space.stamp {
+		return body.transform
+	}
+
+	p := body.prevP.Lerp(body.p, alpha)
+	a := body.prevA + (body.a-body.prevA)*alpha
+	return body.transformAt(p, a)
+}
+
 // Activate wakes up a sleeping or idle body.
 func (body *Body) Activate() {
 	if !(body != nil && body.GetType() == BODY_DYNAMIC) {
@@ -383,6 +590,7 @@ func (body *Body) Activate() {
 	if root != nil && root.IsSleeping() {
 		assert(root.GetType() == BODY_DYNAMIC, "Non-dynamic root")
 		space := root.space
+		var woken []*Body
 		// in the chipmunk code they shadow body, so here I am not
 		bodyToo := root
 		for bodyToo != nil {
@@ -392,6 +600,9 @@ func (body *Body) Activate() {
 			bodyToo.sleepingNext = nil
 			space.Activate(bodyToo)
 
+			if space.OnBodyWake != nil {
+				woken = append(woken, bodyToo)
+			}
 			bodyToo = next
 		}
 
@@ -401,6 +612,11 @@ func (body *Body) Activate() {
 				break
 			}
 		}
+
+		// Call the wake function once the whole component is awake.
+		for _, item := range woken {
+			space.OnBodyWake(item)
+		}
 	}
 
 	for arbiter := body.arbiterList; arbiter != nil; arbiter = arbiter.Next(body) {
@@ -438,6 +654,54 @@ func (body *Body) IsSleeping() bool {
 	return body.sleepingRoot != nil
 }
 
+// Sleep forces the body to fall asleep immediately in its own component, even if it is moving.
+// Use it to start a level with bodies asleep, so they cost nothing until something touches them.
+// Sleeping must be enabled on the space by setting its SleepTimeThreshold.
+func (body *Body) Sleep() {
+	body.SleepWithGroup(nil)
+}
+
+// SleepWithGroup forces the body to fall asleep immediately, even if it is moving.
+// If group is nil, the body starts a new sleeping component. Otherwise group must be a sleeping body,
+// and the body joins its component so they are woken up together.
+// Sleeping must be enabled on the space by setting its SleepTimeThreshold.
+func (body *Body) SleepWithGroup(group *Body) {
+	assert(body.GetType() == BODY_DYNAMIC, "Non-dynamic bodies cannot be put to sleep.")
+
+	space := body.space
+	assert(space != nil, "Cannot put a body to sleep that has not been added to a space.")
+	assert(space.locked == 0, "Bodies cannot be put to sleep during a query or a call to Space.Step(). Put these calls into a post-step callback.")
+	assert(space.SleepTimeThreshold < INFINITY, "Sleeping is not enabled on the space. You cannot sleep a body without setting a sleep time threshold on the space.")
+	assert(group == nil || group.IsSleeping(), "Cannot use a non-sleeping body as a group identifier.")
+
+	if body.IsSleeping() {
+		assert(body.ComponentRoot() == group.ComponentRoot(), "The body is already sleeping and its group cannot be reassigned.")
+		return
+	}
+
+	for _, shape := range body.shapeList {
+		shape.CacheBB()
+	}
+	space.Deactivate(body)
+
+	if group != nil {
+		root := group.ComponentRoot()
+		body.sleepingRoot = root
+		body.sleepingNext = root.sleepingNext
+		body.sleepingIdleTime = 0
+		root.sleepingNext = body
+	} else {
+		body.sleepingRoot = body
+		body.sleepingNext = nil
+		body.sleepingIdleTime = 0
+		space.sleepingComponents = append(space.sleepingComponents, body)
+	}
+
+	if space.OnBodySleep != nil {
+		space.OnBodySleep(body)
+	}
+}
+
 // AddShape adds shape to the body and returns added shape
 func (body *Body) AddShape(shape *Shape) *Shape {
 	body.shapeList = append(body.shapeList, shape)
@@ -620,20 +884,31 @@ func filterConstraints(node *Constraint, body *Body, filter *Constraint) *Constr
 }
 
 // BodyUpdateVelocity is default velocity integration function.
+// The body's gravity scale and the space's gravity fields are applied to gravity, see Body.Gravity.
+// The body's own damping is applied on top of damping, see Body.Damping.
+// The new velocity is clamped to the body's speed limits, see Body.ClampVelocity.
 func BodyUpdateVelocity(body *Body, gravity Vector, damping, dt float64) {
 	if body.GetType() == BODY_KINEMATIC {
 		return
 	}
 
-	body.v = body.v.Mult(damping).Add(gravity.Add(body.f.Mult(body.m_inv)).Mult(dt))
-	body.w = body.w*damping + body.t*body.i_inv*dt
+	gravity = body.Gravity(gravity)
+
+	linear, angular := body.Damping(damping, dt)
+	body.v = body.v.Mult(linear).Add(gravity.Add(body.f.Mult(body.m_inv)).Mult(dt))
+	body.w = body.w*angular + body.t*body.i_inv*dt
+	body.ClampVelocity()
 
 	body.f = Vector{}
 	body.t = 0
 }
 
 // BodyUpdatePosition is default position integration function.
+// The velocity of dynamic bodies is clamped to their speed limits first, since the solver may have sped them up.
 func BodyUpdatePosition(body *Body, dt float64) {
+	if body.GetType() == BODY_DYNAMIC {
+		body.ClampVelocity()
+	}
 	body.p = body.p.Add(body.v.Add(body.v_bias).Mult(dt))
 	body.a = body.a + (body.w+body.w_bias)*dt
 	body.SetTransform(body.p, body.a)
@@ -1,6 +1,7 @@
 package cp
 
 import (
+	"math"
 	"testing"
 )
 
@@ -50,3 +51,146 @@ func TestBodyCoGFromShapes(t *testing.T) {
 	}
 
 }
+
+func TestBodyDamping(t *testing.T) {
+	space := NewSpace()
+	space.SetDamping(0.5)
+
+	newSpinningBody := func() *Body {
+		body := space.AddBody(NewBody(1, 1))
+		body.SetVelocity(100, 0)
+		body.SetAngularVelocity(10)
+		return body
+	}
+
+	plain := newSpinningBody()
+	balloon := newSpinningBody()
+	balloon.SetLinearDamping(0.5)
+	wheel := newSpinningBody()
+	wheel.SetAngularDamping(0.1)
+
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if !near(plain.Velocity().X, 50) || !near(plain.AngularVelocity(), 5) {
+		t.Errorf("expected the space's damping only, got %v %v", plain.Velocity(), plain.AngularVelocity())
+	}
+	if !near(balloon.Velocity().X, 25) || !near(balloon.AngularVelocity(), 5) {
+		t.Errorf("expected extra linear damping, got %v %v", balloon.Velocity(), balloon.AngularVelocity())
+	}
+	if !near(wheel.Velocity().X, 50) || !near(wheel.AngularVelocity(), 0.5) {
+		t.Errorf("expected extra angular damping, got %v %v", wheel.Velocity(), wheel.AngularVelocity())
+	}
+}
+
+func TestBodyDampingWakes(t *testing.T) {
+	space := newSleepySpace()
+	body := addSleepyBox(space, Vector{0, 5})
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if !body.IsSleeping() {
+		t.Fatal("expected the body to fall asleep")
+	}
+
+	body.SetLinearDamping(0.5)
+	if body.IsSleeping() {
+		t.Error("expected changing the linear damping to wake the body")
+	}
+	body.Sleep()
+	body.SetAngularDamping(0.5)
+	if body.IsSleeping() {
+		t.Error("expected changing the angular damping to wake the body")
+	}
+}
+
+func TestBodySpeedLimits(t *testing.T) {
+	space := NewSpace()
+	space.SetMaxLinearSpeed(100)
+	space.SetMaxAngularSpeed(2)
+
+	var clamped []*Body
+	var clampedSpeed float64
+	space.SetSpeedClampFunc(func(body *Body, v Vector, w float64) {
+		clamped = append(clamped, body)
+		clampedSpeed = v.Length()
+	})
+
+	limited := space.AddBody(NewBody(1, 1))
+	limited.SetVelocity(600, 800)
+	limited.SetAngularVelocity(-10)
+
+	unlimited := space.AddBody(NewBody(1, 1))
+	unlimited.SetMaxLinearSpeed(INFINITY)
+	unlimited.SetMaxAngularSpeed(INFINITY)
+	unlimited.SetVelocity(1000, 0)
+	unlimited.SetAngularVelocity(10)
+
+	slower := space.AddBody(NewBody(1, 1))
+	slower.SetMaxLinearSpeed(10)
+	slower.SetVelocity(50, 0)
+
+	space.Step(1.0 / 60.0)
+
+	if v := limited.Velocity(); math.Abs(v.Length()-100) > 1e-9 || math.Abs(v.X-60) > 1e-9 {
+		t.Errorf("expected the velocity to be clamped to (60, 80), got %v", v)
+	}
+	if w := limited.AngularVelocity(); w != -2 {
+		t.Errorf("expected the angular velocity to be clamped to -2, got %v", w)
+	}
+	if v, w := unlimited.Velocity(), unlimited.AngularVelocity(); v.X != 1000 || w != 10 {
+		t.Errorf("expected the body to ignore the space's limits, got %v %v", v, w)
+	}
+	if v := slower.Velocity(); math.Abs(v.X-10) > 1e-9 {
+		t.Errorf("expected the body's own limit, got %v", v)
+	}
+
+	if len(clamped) != 2 || clamped[0] != limited || clamped[1] != slower {
+		t.Errorf("expected the clamp function to be called for the clamped bodies, got %v", clamped)
+	}
+	if clampedSpeed != 50 {
+		t.Errorf("expected the clamp function to get the speed before clamping, got %v", clampedSpeed)
+	}
+}
+
+func TestBodySpeedLimits_Substeps(t *testing.T) {
+	space := NewSpace()
+	space.SetSolver(SolverSubstep, 4)
+	space.SetMaxLinearSpeed(100)
+	// Pushes the dynamic body over the limit again in every substep.
+	space.SetGravity(Vector{100, 0})
+
+	clamps := map[*Body]int{}
+	space.SetSpeedClampFunc(func(body *Body, v Vector, w float64) {
+		clamps[body]++
+	})
+
+	// Kinematic bodies move at the velocity they are given.
+	platform := space.AddBody(NewKinematicBody())
+	platform.SetVelocity(200, 0)
+	fast := space.AddBody(NewBody(1, 1))
+	fast.SetVelocity(200, 0)
+
+	space.Step(1.0 / 60.0)
+
+	if v := platform.Velocity(); v.X != 200 {
+		t.Errorf("expected the kinematic body to keep its velocity, got %v", v)
+	}
+	if p := platform.Position(); math.Abs(p.X-200.0/60.0) > 1e-9 {
+		t.Errorf("expected the kinematic body to move at its velocity, got %v", p)
+	}
+	if v := fast.Velocity(); math.Abs(v.X-100) > 1e-9 {
+		t.Errorf("expected the dynamic body to be clamped, got %v", v)
+	}
+	if clamps[platform] != 0 || clamps[fast] != 1 {
+		t.Errorf("expected one clamp report for the dynamic body, got %v", clamps)
+	}
+
+	fast.SetVelocity(200, 0)
+	space.Step(1.0 / 60.0)
+	if clamps[fast] != 2 {
+		t.Errorf("expected a clamp report in the next step, got %v", clamps[fast])
+	}
+}
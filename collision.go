@@ -87,7 +87,7 @@ func CircleToCircle(info *CollisionInfo) {
 	c1 := info.a.Class.(*Circle)
 	c2 := info.b.Class.(*Circle)
 
-	mindist := c1.r + c2.r
+	mindist := c1.r + c2.r + info.margin
 	delta := c2.tc.Sub(c1.tc)
 	distsq := delta.LengthSq()
 
@@ -118,7 +118,7 @@ func CircleToSegment(info *CollisionInfo) {
 	closestT := Clamp01(segDelta.Dot(center.Sub(segA)) / segDelta.LengthSq())
 	closest := segA.Add(segDelta.Mult(closestT))
 
-	mindist := circle.r + segment.r
+	mindist := circle.r + segment.r + info.margin
 	delta := closest.Sub(center)
 	distsq := delta.LengthSq()
 	if distsq < mindist*mindist {
@@ -149,7 +149,7 @@ func SegmentToSegment(info *CollisionInfo) {
 	rot1 := seg1.body.Rotation()
 	rot2 := seg2.body.Rotation()
 
-	if points.d > (seg1.r + seg2.r) {
+	if points.d > (seg1.r + seg2.r + info.margin) {
 		return
 	}
 
@@ -157,6 +157,10 @@ func SegmentToSegment(info *CollisionInfo) {
 		(!points.a.Equal(seg1.tb) || n.Dot(seg1.b_tangent.Rotate(rot1)) <= 0) &&
 		(!points.b.Equal(seg2.ta) || n.Dot(seg2.a_tangent.Rotate(rot2)) >= 0) &&
 		(!points.b.Equal(seg2.tb) || n.Dot(seg2.b_tangent.Rotate(rot2)) >= 0) {
+		if info.overlapOnly {
+			info.pushClosestPoints(points, seg1.r, seg2.r)
+			return
+		}
 		ContactPoints(SupportEdgeForSegment(seg1, n), SupportEdgeForSegment(seg2, n.Neg()), points, info)
 	}
 }
@@ -168,7 +172,7 @@ func CircleToPoly(info *CollisionInfo) {
 	circle := info.a.Class.(*Circle)
 	poly := info.b.Class.(*PolyShape)
 
-	if points.d <= circle.r+poly.r {
+	if points.d <= circle.r+poly.r+info.margin {
 		info.n = points.n
 		info.PushContact(points.a.Add(info.n.Mult(circle.r)), points.b.Add(info.n.Mult(poly.r)), 0)
 	}
@@ -185,10 +189,14 @@ func SegmentToPoly(info *CollisionInfo) {
 	polyshape := info.b.Class.(*PolyShape)
 
 	// If the closest points are nearer than the sum of the radii...
-	if points.d-segment.r-polyshape.r <= 0 && (
+	if points.d-segment.r-polyshape.r <= info.margin && (
 	// Reject endcap collisions if tangents are provided.
 	(!points.a.Equal(segment.ta) || n.Dot(segment.a_tangent.Rotate(rot)) <= 0) &&
 		(!points.a.Equal(segment.tb) || n.Dot(segment.b_tangent.Rotate(rot)) <= 0)) {
+		if info.overlapOnly {
+			info.pushClosestPoints(points, segment.r, polyshape.r)
+			return
+		}
 		ContactPoints(SupportEdgeForSegment(segment, n), SupportEdgeForPoly(polyshape, n.Neg()), points, info)
 	}
 }
@@ -201,7 +209,11 @@ func PolyToPoly(info *CollisionInfo) {
 
 	poly1 := info.a.Class.(*PolyShape)
 	poly2 := info.b.Class.(*PolyShape)
-	if points.d-poly1.r-poly2.r <= 0 {
+	if points.d-poly1.r-poly2.r <= info.margin {
+		if info.overlapOnly {
+			info.pushClosestPoints(points, poly1.r, poly2.r)
+			return
+		}
 		ContactPoints(SupportEdgeForPoly(poly1, points.n), SupportEdgeForPoly(poly2, points.n.Neg()), points, info)
 	}
 }
@@ -310,7 +322,7 @@ func SupportEdgeForPoly(poly *PolyShape, n Vector) Edge {
 
 // ContactPoints finds contact point pairs on two support edges' surfaces
 func ContactPoints(e1, e2 Edge, points ClosestPoints, info *CollisionInfo) {
-	mindist := e1.r + e2.r
+	mindist := e1.r + e2.r + info.margin
 
 	if points.d > mindist {
 		return
@@ -334,7 +346,7 @@ func ContactPoints(e1, e2 Edge, points ClosestPoints, info *CollisionInfo) {
 		p1 := n.Mult(e1.r).Add(e1.a.p.Lerp(e1.b.p, Clamp01((dE2B-dE1A)*e1Denom)))
 		p2 := n.Mult(-e2.r).Add(e2.a.p.Lerp(e2.b.p, Clamp01((dE1A-dE2A)*e2Denom)))
 		dist := p2.Sub(p1).Dot(n)
-		if dist <= 0 {
+		if dist <= info.margin {
 			hash1a2b := HashPair(e1.a.hash, e2.b.hash)
 			info.PushContact(p1, p2, hash1a2b)
 		}
@@ -343,7 +355,7 @@ func ContactPoints(e1, e2 Edge, points ClosestPoints, info *CollisionInfo) {
 		p1 := n.Mult(e1.r).Add(e1.a.p.Lerp(e1.b.p, Clamp01((dE2A-dE1A)*e1Denom)))
 		p2 := n.Mult(-e2.r).Add(e2.a.p.Lerp(e2.b.p, Clamp01((dE1B-dE2A)*e2Denom)))
 		dist := p2.Sub(p1).Dot(n)
-		if dist <= 0 {
+		if dist <= info.margin {
 			hash1b2a := HashPair(e1.b.hash, e2.a.hash)
 			info.PushContact(p1, p2, hash1b2a)
 		}
@@ -497,10 +509,17 @@ var BuiltinCollisionFuncs = [9]CollisionFunc{
 
 // Collide performs a collision between two shapes
 func Collide(a, b *Shape, collisionID uint32, contacts []Contact) CollisionInfo {
+	return collide(a, b, collisionID, contacts, 0)
+}
+
+// collide also generates speculative contacts for shapes separated by less than margin.
+func collide(a, b *Shape, collisionID uint32, contacts []Contact, margin float64) CollisionInfo {
 	info := CollisionInfo{
 		a:           a,
 		b:           b,
 		collisionId: collisionID,
+		margin:      margin,
+		overlapOnly: (a.sensor && a.sensorOverlapOnly) || (b.sensor && b.sensorOverlapOnly),
 		arr:         contacts,
 	}
 
@@ -0,0 +1,259 @@
+package cp
+
+// CollisionPredicate limits a PriorityHandler to some of the collisions between its types.
+// The arbiter's shapes are in the order of the handler's types.
+type CollisionPredicate func(arb *Arbiter, space *Space) bool
+
+// PriorityHandler is a collision handler in the chain for a pair of collision types, added with Space.AddCollisionHandler.
+//
+// The handlers in a chain are called from the highest priority to the lowest, and in the order they were added for equal priorities.
+// The pair's handler from Space.NewCollisionHandler is part of the chain with priority 0.
+// Begin and pre-solve functions pass a collision on to the next handler by returning true, or veto it by returning false,
+// which rejects the collision and skips the rest of the chain. Post-solve and separate functions are only called for the handlers
+// whose begin function was called for the collision, so a handler added during a collision or skipped by a veto isn't called for it.
+type PriorityHandler struct {
+	*CollisionHandler
+
+	// Priority orders the handler in its chain. Read only.
+	Priority int
+	// Predicate limits the handler to the collisions it returns true for. All collisions match if it is nil.
+	Predicate CollisionPredicate
+
+	chain   *handlerChain
+	removed bool
+}
+
+// handlerChain is the user data of the handler that calls the chain for a pair of collision types.
+type handlerChain struct {
+	dispatcher *CollisionHandler
+	main       *PriorityHandler
+
+	// Sorted from the highest priority to the lowest.
+	// The slice is replaced instead of modified, so callbacks can add or remove handlers while it is being called.
+	handlers []*PriorityHandler
+}
+
+// AddCollisionHandler adds a handler to the chain for collisions between shapes with collision types a and b.
+// Set its functions to handle the collisions, by default it passes them on to the rest of the chain.
+func (space *Space) AddCollisionHandler(a, b CollisionType, priority int) *PriorityHandler {
+	if b == WILDCARD_COLLISION_TYPE {
+		space.UseWildcardDefaultHandler()
+	}
+
+	chain := space.handlerChain(a, b)
+	handler := &PriorityHandler{
+		CollisionHandler: &CollisionHandler{a, b, AlwaysCollide, AlwaysCollide, DoNothing, DoNothing, nil},
+		Priority:         priority,
+		chain:            chain,
+	}
+	chain.insert(handler)
+	return handler
+}
+
+// AddWildcardHandler adds a handler to the chain for collisions between shapes with collision type t and any other shape.
+// Like the handlers from NewWildcardCollisionHandler, it is only called by the other handlers for the collision.
+func (space *Space) AddWildcardHandler(t CollisionType, priority int) *PriorityHandler {
+	return space.AddCollisionHandler(t, WILDCARD_COLLISION_TYPE, priority)
+}
+
+// RemoveHandler removes a handler added with AddCollisionHandler or AddWildcardHandler, so it is not called again.
+// It is safe to call from collision callbacks, including the handler's own.
+func (space *Space) RemoveHandler(handler *PriorityHandler) {
+	handler.chain.remove(handler)
+}
+
+// RemoveCollisionHandler removes the handler for collisions between shapes with collision types a and b,
+// along with the handlers added for the pair with AddCollisionHandler. The removed handlers are never called again.
+// Ongoing collisions between the types fall back to the default handler, which is also called when they separate.
+// It is safe to call from collision callbacks.
+func (space *Space) RemoveCollisionHandler(a, b CollisionType) {
+	hash := HashPair(HashValue(a), HashValue(b))
+	handler := space.collisionHandlers.Remove(hash, &CollisionHandler{TypeA: a, TypeB: b})
+	if handler == nil {
+		return
+	}
+
+	if chain, ok := handler.UserData.(*handlerChain); ok {
+		// Stop the chain if it is being called.
+		for _, h := range chain.handlers {
+			h.removed = true
+		}
+		chain.handlers = nil
+	}
+
+	space.replaceHandler(handler)
+}
+
+// RemoveWildcardHandler removes the wildcard handler for collision type t, along with the handlers added with AddWildcardHandler.
+// The removed handlers are never called again.
+func (space *Space) RemoveWildcardHandler(t CollisionType) {
+	space.RemoveCollisionHandler(t, WILDCARD_COLLISION_TYPE)
+}
+
+// replaceHandler looks up new handlers for the arbiters that use a removed handler, including the arbiters of sleeping bodies.
+func (space *Space) replaceHandler(handler *CollisionHandler) {
+	replace := func(arb *Arbiter) {
+		if arb.handler != handler && arb.handlerA != handler && arb.handlerB != handler {
+			return
+		}
+
+		// Callbacks for wildcards and chained handlers swap the shapes while they run, and swap them back afterwards.
+		typeA := arb.a.collisionType
+		swapping := arb.swapped != (typeA != arb.handler.TypeA && arb.handler.TypeA != WILDCARD_COLLISION_TYPE)
+
+		arb.lookupHandlers(space)
+		if swapping {
+			arb.swapped = !arb.swapped
+		}
+	}
+
+	space.eachArbiter(replace)
+}
+
+// handlerChain returns the chain for a pair of collision types.
+// The first time, the pair's handler is moved into a new chain and replaced by one that calls the chain.
+func (space *Space) handlerChain(a, b CollisionType) *handlerChain {
+	hash := HashPair(HashValue(a), HashValue(b))
+	handler := space.collisionHandlers.Find(hash, &CollisionHandler{TypeA: a, TypeB: b})
+	if handler != nil {
+		if chain, ok := handler.UserData.(*handlerChain); ok {
+			return chain
+		}
+		space.collisionHandlers.Remove(hash, handler)
+	} else if b == WILDCARD_COLLISION_TYPE {
+		handler = &CollisionHandler{a, b, AlwaysCollide, AlwaysCollide, DoNothing, DoNothing, nil}
+	} else {
+		handler = &CollisionHandler{a, b, DefaultBegin, DefaultPreSolve, DefaultPostSolve, DefaultSeparate, nil}
+	}
+
+	chain := &handlerChain{}
+	chain.main = &PriorityHandler{CollisionHandler: handler, chain: chain}
+	chain.handlers = []*PriorityHandler{chain.main}
+	chain.dispatcher = &CollisionHandler{handler.TypeA, handler.TypeB, chainBegin, chainPreSolve, chainPostSolve, chainSeparate, chain}
+	space.collisionHandlers.Insert(hash, chain.dispatcher, func(a *CollisionHandler) *CollisionHandler { return a })
+	return chain
+}
+
+func (chain *handlerChain) insert(handler *PriorityHandler) {
+	i := 0
+	for i < len(chain.handlers) && chain.handlers[i].Priority >= handler.Priority {
+		i++
+	}
+
+	handlers := make([]*PriorityHandler, 0, len(chain.handlers)+1)
+	handlers = append(handlers, chain.handlers[:i]...)
+	handlers = append(handlers, handler)
+	chain.handlers = append(handlers, chain.handlers[i:]...)
+}
+
+func (chain *handlerChain) remove(handler *PriorityHandler) {
+	handler.removed = true
+
+	handlers := make([]*PriorityHandler, 0, len(chain.handlers))
+	for _, h := range chain.handlers {
+		if h != handler {
+			handlers = append(handlers, h)
+		}
+	}
+	chain.handlers = handlers
+}
+
+// each calls f for the handlers that match the collision until it returns false, with the arbiter's shapes in the order of each handler's types.
+func (chain *handlerChain) each(arb *Arbiter, space *Space, f func(handler *PriorityHandler) bool) bool {
+	for _, handler := range chain.handlers {
+		if handler.removed {
+			continue
+		}
+
+		swap := handler.TypeA != chain.dispatcher.TypeA
+		if swap {
+			arb.swapped = !arb.swapped
+		}
+
+		pass := true
+		if handler.Predicate == nil || handler.Predicate(arb, space) {
+			pass = f(handler)
+		}
+
+		if swap {
+			arb.swapped = !arb.swapped
+		}
+		if !pass {
+			return false
+		}
+	}
+	return true
+}
+
+// begun returns true if the handler's begin function was called for the arbiter's collision.
+func (arb *Arbiter) begun(handler *PriorityHandler) bool {
+	for _, h := range arb.begunHandlers {
+		if h == handler {
+			return true
+		}
+	}
+	return false
+}
+
+func chainBegin(arb *Arbiter, space *Space, userData interface{}) bool {
+	chain := userData.(*handlerChain)
+
+	// Forget the handlers of a previous collision between the shapes. The wildcard chains share the slice.
+	begun := arb.begunHandlers[:0]
+	for _, h := range arb.begunHandlers {
+		if h.chain != chain {
+			begun = append(begun, h)
+		}
+	}
+	arb.begunHandlers = begun
+
+	return chain.each(arb, space, func(handler *PriorityHandler) bool {
+		arb.begunHandlers = append(arb.begunHandlers, handler)
+		return handler.BeginFunc(arb, space, handler.UserData)
+	})
+}
+
+// adopt treats an ongoing collision that started before the chain was created as begun by the pair's handler,
+// whose begin function was called directly.
+func (chain *handlerChain) adopt(arb *Arbiter) {
+	if arb.state == CP_ARBITER_STATE_FIRST_COLLISION {
+		return
+	}
+
+	for _, h := range arb.begunHandlers {
+		if h.chain == chain {
+			return
+		}
+	}
+	arb.begunHandlers = append(arb.begunHandlers, chain.main)
+}
+
+func chainPreSolve(arb *Arbiter, space *Space, userData interface{}) bool {
+	chain := userData.(*handlerChain)
+	chain.adopt(arb)
+	return chain.each(arb, space, func(handler *PriorityHandler) bool {
+		return handler.PreSolveFunc(arb, space, handler.UserData)
+	})
+}
+
+func chainPostSolve(arb *Arbiter, space *Space, userData interface{}) {
+	chain := userData.(*handlerChain)
+	chain.adopt(arb)
+	chain.each(arb, space, func(handler *PriorityHandler) bool {
+		if arb.begun(handler) {
+			handler.PostSolveFunc(arb, space, handler.UserData)
+		}
+		return true
+	})
+}
+
+func chainSeparate(arb *Arbiter, space *Space, userData interface{}) {
+	chain := userData.(*handlerChain)
+	chain.adopt(arb)
+	chain.each(arb, space, func(handler *PriorityHandler) bool {
+		if arb.begun(handler) {
+			handler.SeparateFunc(arb, space, handler.UserData)
+		}
+		return true
+	})
+}
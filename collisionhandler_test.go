@@ -0,0 +1,368 @@
+package cp
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	testGroundType CollisionType = iota + 1
+	testBallType
+)
+
+// newHandlerSpace drops a ball onto the ground, returning the space and the ball.
+func newHandlerSpace() (*Space, *Body) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+
+	ground := space.AddShape(NewSegment(space.StaticBody, Vector{-100, 0}, Vector{100, 0}, 0))
+	ground.SetCollisionType(testGroundType)
+
+	ball := space.AddBody(NewBody(1, MomentForCircle(1, 0, 5, Vector{})))
+	ball.SetPosition(Vector{0, 6})
+	space.AddShape(NewCircle(ball, 5, Vector{})).SetCollisionType(testBallType)
+	return space, ball
+}
+
+func stepHandlerSpace(space *Space) {
+	for i := 0; i < 30; i++ {
+		space.Step(1.0 / 60.0)
+	}
+}
+
+func TestPriorityHandler_Order(t *testing.T) {
+	space, _ := newHandlerSpace()
+
+	var calls []string
+	record := func(name string) CollisionBeginFunc {
+		return func(arb *Arbiter, space *Space, userData interface{}) bool {
+			calls = append(calls, name)
+			return true
+		}
+	}
+
+	space.AddCollisionHandler(testGroundType, testBallType, -5).BeginFunc = record("low")
+	space.NewCollisionHandler(testGroundType, testBallType).BeginFunc = record("main")
+	space.AddCollisionHandler(testBallType, testGroundType, 10).BeginFunc = record("high")
+	space.AddCollisionHandler(testGroundType, testBallType, 10).BeginFunc = record("high2")
+
+	stepHandlerSpace(space)
+
+	expected := []string{"high", "high2", "main", "low"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected %v, got %v", expected, calls)
+	}
+}
+
+func TestPriorityHandler_Veto(t *testing.T) {
+	space, ball := newHandlerSpace()
+
+	var vetoes, low handlerCounts
+	veto := space.AddCollisionHandler(testGroundType, testBallType, 1)
+	countCalls(veto.CollisionHandler, &vetoes)
+	veto.BeginFunc = func(arb *Arbiter, space *Space, userData interface{}) bool {
+		vetoes.begin++
+		return false
+	}
+	countCalls(space.AddCollisionHandler(testGroundType, testBallType, -1).CollisionHandler, &low)
+
+	stepHandlerSpace(space)
+
+	if low != (handlerCounts{}) {
+		t.Errorf("expected the veto to skip the rest of the chain, got %+v", low)
+	}
+	if ball.Position().Y > 0 {
+		t.Error("expected the vetoed ball to fall through the ground")
+	}
+	if vetoes.begin != 1 || vetoes.separate != 1 {
+		t.Errorf("expected separate to be called for the handler that vetoed the collision, got %+v", vetoes)
+	}
+}
+
+func TestPriorityHandler_AddedDuringCollision(t *testing.T) {
+	space, ball := newHandlerSpace()
+	stepHandlerSpace(space)
+
+	// The handler is added after the collision began, so it doesn't see the collision end either.
+	var counts handlerCounts
+	countCalls(space.AddCollisionHandler(testGroundType, testBallType, 1).CollisionHandler, &counts)
+	stepHandlerSpace(space)
+	ball.SetPosition(Vector{0, 50})
+	stepHandlerSpace(space)
+
+	if counts.begin != 0 || counts.preSolve == 0 || counts.postSolve != 0 || counts.separate != 0 {
+		t.Errorf("expected only pre-solve to be called for the ongoing collision, got %+v", counts)
+	}
+
+	// It is called for the next collision.
+	stepHandlerSpace(space)
+	stepHandlerSpace(space)
+	if counts.begin != 1 || counts.postSolve == 0 {
+		t.Errorf("expected the handler to be called for a new collision, got %+v", counts)
+	}
+}
+
+func TestPriorityHandler_ChainDuringCollision(t *testing.T) {
+	space, ball := newHandlerSpace()
+
+	var counts handlerCounts
+	countCalls(space.NewCollisionHandler(testGroundType, testBallType), &counts)
+	stepHandlerSpace(space)
+
+	// Adding a handler turns the pair's handler into a chain while the collision is ongoing.
+	space.AddCollisionHandler(testGroundType, testBallType, 1)
+	stepHandlerSpace(space)
+	postSolves := counts.postSolve
+	ball.SetPosition(Vector{0, 50})
+	stepHandlerSpace(space)
+
+	if counts.begin != 1 || counts.separate != 1 || postSolves <= 30 {
+		t.Errorf("expected the pair's handler to keep being called until the collision ends, got %+v", counts)
+	}
+}
+
+func TestPriorityHandler_ShapeOrder(t *testing.T) {
+	space, _ := newHandlerSpace()
+
+	check := func(first CollisionType) CollisionPreSolveFunc {
+		return func(arb *Arbiter, space *Space, userData interface{}) bool {
+			if a, _ := arb.Shapes(); a.collisionType != first {
+				t.Errorf("expected the first shape to have type %v, got %v", first, a.collisionType)
+			}
+			return true
+		}
+	}
+
+	space.NewCollisionHandler(testGroundType, testBallType).PreSolveFunc = check(testGroundType)
+	space.AddCollisionHandler(testBallType, testGroundType, 1).PreSolveFunc = check(testBallType)
+	space.AddCollisionHandler(testGroundType, testBallType, 2).PreSolveFunc = check(testGroundType)
+
+	stepHandlerSpace(space)
+}
+
+func TestPriorityHandler_Predicate(t *testing.T) {
+	space, ball := newHandlerSpace()
+
+	other := space.AddBody(NewBody(1, MomentForCircle(1, 0, 5, Vector{})))
+	other.SetPosition(Vector{50, 6})
+	space.AddShape(NewCircle(other, 5, Vector{})).SetCollisionType(testBallType)
+
+	seen := map[*Body]int{}
+	handler := space.AddCollisionHandler(testBallType, testGroundType, 0)
+	handler.Predicate = func(arb *Arbiter, space *Space) bool {
+		a, _ := arb.Bodies()
+		return a == ball
+	}
+	handler.PostSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) {
+		a, _ := arb.Bodies()
+		seen[a]++
+	}
+
+	stepHandlerSpace(space)
+
+	if seen[ball] == 0 || seen[other] != 0 {
+		t.Errorf("expected only the ball to match the predicate, got %v", seen)
+	}
+}
+
+func TestPriorityHandler_Remove(t *testing.T) {
+	space, _ := newHandlerSpace()
+
+	var onceCalls, keptCalls int
+	once := space.AddCollisionHandler(testGroundType, testBallType, 1)
+	once.PreSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) bool {
+		onceCalls++
+		space.RemoveHandler(once)
+		return true
+	}
+	kept := space.AddCollisionHandler(testGroundType, testBallType, 0)
+	kept.PreSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) bool {
+		keptCalls++
+		return true
+	}
+
+	stepHandlerSpace(space)
+
+	if onceCalls != 1 {
+		t.Errorf("expected the removed handler to be called once, got %v", onceCalls)
+	}
+	if keptCalls < 2 {
+		t.Errorf("expected the rest of the chain to keep being called, got %v", keptCalls)
+	}
+}
+
+func TestPriorityHandler_Wildcard(t *testing.T) {
+	space, _ := newHandlerSpace()
+
+	var calls []string
+	space.NewWildcardCollisionHandler(testBallType).PostSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) {
+		calls = append(calls, userData.(string))
+	}
+	space.NewWildcardCollisionHandler(testBallType).UserData = "main"
+	space.AddWildcardHandler(testBallType, 1).PostSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) {
+		if a, _ := arb.Shapes(); a.collisionType != testBallType {
+			t.Errorf("expected the wildcard's type first, got %v", a.collisionType)
+		}
+		calls = append(calls, "added")
+	}
+
+	stepHandlerSpace(space)
+
+	expected := []string{"added", "main"}
+	if len(calls) < 2 || !reflect.DeepEqual(calls[:2], expected) {
+		t.Errorf("expected %v, got %v", expected, calls)
+	}
+}
+
+func TestCollisionHandler_UserData(t *testing.T) {
+	space, _ := newHandlerSpace()
+
+	seen := map[string]interface{}{}
+	handler := space.NewCollisionHandler(testGroundType, testBallType)
+	handler.UserData = "data"
+	handler.BeginFunc = func(arb *Arbiter, space *Space, userData interface{}) bool {
+		seen["begin"] = userData
+		return true
+	}
+	handler.PreSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) bool {
+		seen["preSolve"] = userData
+		return true
+	}
+	handler.PostSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) {
+		seen["postSolve"] = userData
+	}
+
+	stepHandlerSpace(space)
+
+	expected := map[string]interface{}{"begin": "data", "preSolve": "data", "postSolve": "data"}
+	if !reflect.DeepEqual(seen, expected) {
+		t.Errorf("expected every callback to get the handler's user data, got %v", seen)
+	}
+}
+
+type handlerCounts struct {
+	begin, preSolve, postSolve, separate int
+}
+
+func countCalls(handler *CollisionHandler, counts *handlerCounts) {
+	handler.BeginFunc = func(arb *Arbiter, space *Space, userData interface{}) bool {
+		counts.begin++
+		return true
+	}
+	handler.PreSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) bool {
+		counts.preSolve++
+		return true
+	}
+	handler.PostSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) {
+		counts.postSolve++
+	}
+	handler.SeparateFunc = func(arb *Arbiter, space *Space, userData interface{}) {
+		counts.separate++
+	}
+}
+
+func TestSpace_RemoveCollisionHandler(t *testing.T) {
+	space, ball := newHandlerSpace()
+
+	var removed, chained handlerCounts
+	countCalls(space.NewCollisionHandler(testGroundType, testBallType), &removed)
+	countCalls(space.AddCollisionHandler(testBallType, testGroundType, 1).CollisionHandler, &chained)
+	stepHandlerSpace(space)
+	if removed.preSolve == 0 || chained.preSolve == 0 {
+		t.Fatal("expected the handlers to be called")
+	}
+
+	// Lift the ball off the ground so the collision separates right after removing the handlers.
+	space.RemoveCollisionHandler(testBallType, testGroundType)
+	before, beforeChained := removed, chained
+	ball.SetPosition(Vector{0, 50})
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	if removed != before || chained != beforeChained {
+		t.Errorf("expected the removed handlers not to be called, got %+v and %+v", removed, chained)
+	}
+	if ball.Position().Y < 0 {
+		t.Error("expected the ball to keep colliding with the default handler")
+	}
+
+	// Registering the types again starts over with a new handler.
+	var added handlerCounts
+	countCalls(space.NewCollisionHandler(testGroundType, testBallType), &added)
+	stepHandlerSpace(space)
+	if added.preSolve == 0 {
+		t.Errorf("expected the new handler to take over the collision, got %+v", added)
+	}
+}
+
+func TestSpace_RemoveCollisionHandlerInCallback(t *testing.T) {
+	space, ball := newHandlerSpace()
+
+	var counts handlerCounts
+	countCalls(space.NewCollisionHandler(testBallType, testGroundType), &counts)
+
+	// A chained handler with the types reversed swaps the shapes while it runs.
+	space.AddCollisionHandler(testGroundType, testBallType, 1).PreSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) bool {
+		space.RemoveCollisionHandler(testBallType, testGroundType)
+		if a, _ := arb.Shapes(); a.collisionType != testGroundType {
+			t.Error("expected the shapes to stay in the handler's order during the callback")
+		}
+		return true
+	}
+
+	stepHandlerSpace(space)
+
+	for _, arb := range space.arbiters {
+		if expected := arb.a.collisionType != arb.handler.TypeA && arb.handler.TypeA != WILDCARD_COLLISION_TYPE; arb.swapped != expected {
+			t.Error("expected the shapes to be in the order of the new handler")
+		}
+	}
+
+	ball.SetPosition(Vector{0, 50})
+	stepHandlerSpace(space)
+
+	if counts.begin != 1 || counts.preSolve != 0 || counts.separate != 0 {
+		t.Errorf("expected the removed handler not to be called after its begin, got %+v", counts)
+	}
+}
+
+func TestSpace_RemoveWildcardHandler(t *testing.T) {
+	space, _ := newHandlerSpace()
+
+	var counts, added handlerCounts
+	countCalls(space.NewWildcardCollisionHandler(testBallType), &counts)
+	countCalls(space.AddWildcardHandler(testBallType, 1).CollisionHandler, &added)
+	stepHandlerSpace(space)
+	if counts.preSolve == 0 || added.preSolve == 0 {
+		t.Fatal("expected the wildcard handlers to be called")
+	}
+
+	space.RemoveWildcardHandler(testBallType)
+	before, beforeAdded := counts, added
+	stepHandlerSpace(space)
+
+	if counts != before || added != beforeAdded {
+		t.Errorf("expected the removed wildcard handlers not to be called, got %+v and %+v", counts, added)
+	}
+}
+
+func TestSpace_RemoveCollisionHandlerSleeping(t *testing.T) {
+	space, ball := newHandlerSpace()
+	space.SleepTimeThreshold = 0.25
+
+	var counts handlerCounts
+	countCalls(space.NewCollisionHandler(testBallType, testGroundType), &counts)
+	stepHandlerSpace(space)
+	if !ball.IsSleeping() {
+		t.Fatal("expected the ball to fall asleep")
+	}
+
+	space.RemoveCollisionHandler(testBallType, testGroundType)
+	ball.SetPosition(Vector{0, 50})
+	stepHandlerSpace(space)
+
+	if counts.separate != 0 {
+		t.Errorf("expected the removed handler not to be called when the sleeping ball separates, got %+v", counts)
+	}
+}
@@ -0,0 +1,62 @@
+package cp_test
+
+import (
+	"testing"
+
+	"github.com/undefinedopcode/cp/v2"
+	"github.com/undefinedopcode/cp/v2/spatialindextest"
+)
+
+func newTestSpaceHash(bbfunc cp.SpatialIndexBB, staticIndex *cp.SpatialIndex) *cp.SpatialIndex {
+	return cp.NewSpaceHash(40, 1000, bbfunc, staticIndex)
+}
+
+// Smaller than the test world so objects outside of the bounds are covered too.
+func newTestGrid(bbfunc cp.SpatialIndexBB, staticIndex *cp.SpatialIndex) *cp.SpatialIndex {
+	return cp.NewGrid(40, cp.NewBB(0, 0, 1000, 1000), bbfunc, staticIndex)
+}
+
+// Smaller than the test world so objects outside of the bounds are covered too.
+func newTestQuadTree(bbfunc cp.SpatialIndexBB, staticIndex *cp.SpatialIndex) *cp.SpatialIndex {
+	return cp.NewQuadTree(cp.NewBB(0, 0, 1000, 1000), 6, bbfunc, staticIndex)
+}
+
+func TestConformance_BBTree(t *testing.T) {
+	spatialindextest.Run(t, cp.NewBBTree)
+}
+
+func TestConformance_SpaceHash(t *testing.T) {
+	spatialindextest.Run(t, newTestSpaceHash)
+}
+
+func TestConformance_Sweep1D(t *testing.T) {
+	spatialindextest.Run(t, cp.NewSweep1D)
+}
+
+func TestConformance_Grid(t *testing.T) {
+	spatialindextest.Run(t, newTestGrid)
+}
+
+func TestConformance_QuadTree(t *testing.T) {
+	spatialindextest.Run(t, newTestQuadTree)
+}
+
+func BenchmarkBBTree(b *testing.B) {
+	spatialindextest.Benchmark(b, cp.NewBBTree)
+}
+
+func BenchmarkSpaceHash(b *testing.B) {
+	spatialindextest.Benchmark(b, newTestSpaceHash)
+}
+
+func BenchmarkSweep1D(b *testing.B) {
+	spatialindextest.Benchmark(b, cp.NewSweep1D)
+}
+
+func BenchmarkGrid(b *testing.B) {
+	spatialindextest.Benchmark(b, newTestGrid)
+}
+
+func BenchmarkQuadTree(b *testing.B) {
+	spatialindextest.Benchmark(b, newTestQuadTree)
+}
@@ -0,0 +1,300 @@
+package cp
+
+// ContactBeginEvent is recorded when two shapes start touching.
+// Shapes with speculative contacts that pass close by each other without touching record no events.
+// The shapes and bodies are in the order of the collision handler for their types.
+type ContactBeginEvent struct {
+	ShapeA, ShapeB *Shape
+	BodyA, BodyB   *Body
+	// Points are the contact points of the step the shapes started touching in.
+	Points ContactPointSet
+	// Impulse is the total impulse applied to ShapeB's body in that step, as returned by Arbiter.TotalImpulse.
+	Impulse Vector
+}
+
+// ContactEndEvent is recorded when two shapes that recorded a ContactBeginEvent stop touching.
+type ContactEndEvent struct {
+	ShapeA, ShapeB *Shape
+	BodyA, BodyB   *Body
+	// Removed is true if the contact ended because one of the shapes was removed from the space.
+	Removed bool
+}
+
+// ContactHitEvent is recorded when two shapes collide with an approach speed above the space's hit event threshold,
+// and a normal impulse of at least its hit event impulse threshold, such as to play a sound whose volume depends on how hard they hit.
+type ContactHitEvent struct {
+	ShapeA, ShapeB *Shape
+	BodyA, BodyB   *Body
+	// Point is the contact point with the highest approach speed, and Normal the normal of the collision.
+	Point, Normal Vector
+	// ApproachSpeed is the speed the shapes approached each other at along the normal, before the solver ran.
+	ApproachSpeed float64
+	// Impulse is the total impulse applied to ShapeB's body, as returned by Arbiter.TotalImpulse.
+	Impulse Vector
+	// MaxImpulse is the largest normal impulse applied at the point during the step, as returned by Arbiter.MaxNormalImpulse.
+	MaxImpulse float64
+	// KineticEnergy is the energy lost in the collision, as returned by Arbiter.TotalKE.
+	KineticEnergy float64
+}
+
+// SensorEvent is recorded when a shape starts or stops overlapping a sensor shape.
+type SensorEvent struct {
+	Sensor, Visitor         *Shape
+	SensorBody, VisitorBody *Body
+	// Begin is true when the shapes start overlapping, and false when they stop.
+	Begin bool
+}
+
+// contactEvents buffers the events of a space until they are read.
+type contactEvents struct {
+	enabled             bool
+	hitThreshold        float64
+	hitImpulseThreshold float64
+
+	begin  []ContactBeginEvent
+	end    []ContactEndEvent
+	hit    []ContactHitEvent
+	sensor []SensorEvent
+}
+
+// SetContactEvents enables or disables recording contact events.
+// Events are an alternative to collision handler callbacks that can be read after Space.Step returns, when the space is unlocked.
+// They are recorded for every pair of shapes, regardless of their collision handlers, unless a begin or pre-solve function rejects the collision.
+// Disabling them clears the events that haven't been read.
+func (space *Space) SetContactEvents(enabled bool) {
+	if space.events.enabled == enabled {
+		return
+	}
+
+	space.events.enabled = enabled
+	if !enabled {
+		space.events.begin = nil
+		space.events.end = nil
+		space.events.hit = nil
+		space.events.sensor = nil
+
+		space.eachArbiter(func(arb *Arbiter) {
+			arb.eventBegun = false
+		})
+	}
+}
+
+// ContactEvents returns true if the space records contact events.
+func (space *Space) ContactEvents() bool {
+	return space.events.enabled
+}
+
+// SetHitEventThreshold sets the approach speed above which collisions record a ContactHitEvent. Defaults to 1.
+func (space *Space) SetHitEventThreshold(speed float64) {
+	space.events.hitThreshold = speed
+}
+
+func (space *Space) HitEventThreshold() float64 {
+	return space.events.hitThreshold
+}
+
+// SetHitEventImpulseThreshold sets the minimum normal impulse of a collision that records a ContactHitEvent. Defaults to 0.
+// Objects resting on each other are pushed apart with an impulse of about their weight times the time step every step,
+// so a threshold above that leaves out the hits of objects jostling each other while they rest.
+func (space *Space) SetHitEventImpulseThreshold(impulse float64) {
+	space.events.hitImpulseThreshold = impulse
+}
+
+func (space *Space) HitEventImpulseThreshold() float64 {
+	return space.events.hitImpulseThreshold
+}
+
+// ContactBeginEvents returns the contact begin events recorded since they were last read, and clears them.
+// Events accumulate over several steps until they are read, so none are lost when a Stepper runs more than one step per frame.
+func (space *Space) ContactBeginEvents() []ContactBeginEvent {
+	events := space.events.begin
+	space.events.begin = nil
+	return events
+}
+
+// ContactEndEvents returns the contact end events recorded since they were last read, and clears them.
+func (space *Space) ContactEndEvents() []ContactEndEvent {
+	events := space.events.end
+	space.events.end = nil
+	return events
+}
+
+// HitEvents returns the hit events recorded since they were last read, and clears them.
+func (space *Space) HitEvents() []ContactHitEvent {
+	events := space.events.hit
+	space.events.hit = nil
+	return events
+}
+
+// SensorEvents returns the sensor events recorded since they were last read, and clears them.
+func (space *Space) SensorEvents() []SensorEvent {
+	events := space.events.sensor
+	space.events.sensor = nil
+	return events
+}
+
+// SensorEnterEvents returns the sensor events of shapes that started overlapping a sensor since they were last read, and clears them.
+// Exit events are left to SensorExitEvents or SensorEvents.
+func (space *Space) SensorEnterEvents() []SensorEvent {
+	return space.takeSensorEvents(true)
+}
+
+// SensorExitEvents returns the sensor events of shapes that stopped overlapping a sensor since they were last read, and clears them.
+// Enter events are left to SensorEnterEvents or SensorEvents.
+func (space *Space) SensorExitEvents() []SensorEvent {
+	return space.takeSensorEvents(false)
+}
+
+func (space *Space) takeSensorEvents(begin bool) []SensorEvent {
+	var taken []SensorEvent
+	kept := space.events.sensor[:0]
+	for _, event := range space.events.sensor {
+		if event.Begin == begin {
+			taken = append(taken, event)
+		} else {
+			kept = append(kept, event)
+		}
+	}
+
+	// Clear the tail so the shapes can be garbage collected.
+	for i := len(kept); i < len(space.events.sensor); i++ {
+		space.events.sensor[i] = SensorEvent{}
+	}
+	space.events.sensor = kept
+	return taken
+}
+
+// SensorOverlaps returns the shapes overlapping a sensor shape, or the sensors overlapping a shape that isn't one,
+// as of the last step. Overlaps rejected by a begin function are left out.
+// Unlike sensor events, the overlaps are tracked whether contact events are enabled or not.
+func (space *Space) SensorOverlaps(shape *Shape) []*Shape {
+	if len(shape.sensorOverlaps) == 0 {
+		return nil
+	}
+	return append([]*Shape(nil), shape.sensorOverlaps...)
+}
+
+// setSensorOverlap adds or removes the shapes of an arbiter with a sensor shape from each other's sensor overlaps.
+func (arb *Arbiter) setSensorOverlap(overlap bool) {
+	if arb.sensorOverlap == overlap {
+		return
+	}
+
+	arb.sensorOverlap = overlap
+	if overlap {
+		arb.a.sensorOverlaps = append(arb.a.sensorOverlaps, arb.b)
+		arb.b.sensorOverlaps = append(arb.b.sensorOverlaps, arb.a)
+	} else {
+		arb.a.sensorOverlaps = removeShape(arb.a.sensorOverlaps, arb.b)
+		arb.b.sensorOverlaps = removeShape(arb.b.sensorOverlaps, arb.a)
+	}
+}
+
+func removeShape(shapes []*Shape, shape *Shape) []*Shape {
+	for i, s := range shapes {
+		if s == shape {
+			// leak-free delete from slice
+			last := len(shapes) - 1
+			shapes[i] = shapes[last]
+			shapes[last] = nil
+			return shapes[:last]
+		}
+	}
+	return shapes
+}
+
+// recordSolvedEvents records the begin and hit events of an arbiter after the solver ran.
+func (space *Space) recordSolvedEvents(arb *Arbiter) {
+	if !space.events.enabled {
+		return
+	}
+
+	a, b := arb.Shapes()
+	if !arb.eventBegun && touched(arb) {
+		arb.eventBegun = true
+		space.events.begin = append(space.events.begin, ContactBeginEvent{
+			ShapeA: a, ShapeB: b,
+			BodyA: a.body, BodyB: b.body,
+			Points:  arb.ContactPointSet(),
+			Impulse: arb.TotalImpulse(),
+		})
+	}
+
+	// Only count the contacts the solver pushed on, speculative contacts may not have touched.
+	hit := -1
+	for i := 0; i < arb.count; i++ {
+		con := &arb.contacts[i]
+		if con.jnMax > 0 && con.jnMax >= space.events.hitImpulseThreshold && con.approach > space.events.hitThreshold &&
+			(hit < 0 || con.approach > arb.contacts[hit].approach) {
+			hit = i
+		}
+	}
+	if hit >= 0 {
+		con := &arb.contacts[hit]
+		space.events.hit = append(space.events.hit, ContactHitEvent{
+			ShapeA: a, ShapeB: b,
+			BodyA: a.body, BodyB: b.body,
+			Point:         arb.contactPoint(hit),
+			Normal:        arb.Normal(),
+			ApproachSpeed: con.approach,
+			Impulse:       arb.TotalImpulse(),
+			MaxImpulse:    con.jnMax,
+			KineticEnergy: arb.TotalKE(),
+		})
+	}
+}
+
+// touched returns true if the arbiter's shapes overlap, or the solver pushed on one of its contacts.
+// Speculative contacts are also created for shapes that are only close to each other, which may never touch.
+func touched(arb *Arbiter) bool {
+	a, b := arb.body_a, arb.body_b
+	for i := 0; i < arb.count; i++ {
+		con := &arb.contacts[i]
+		if con.jnMax > 0 || b.p.Add(con.r2).Sub(a.p.Add(con.r1)).Dot(arb.n) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// recordSensorBegin records a sensor event for an arbiter with a sensor shape that wasn't rejected.
+func (space *Space) recordSensorBegin(arb *Arbiter) {
+	if !space.events.enabled || arb.eventBegun {
+		return
+	}
+
+	arb.eventBegun = true
+	space.events.sensor = append(space.events.sensor, sensorEvent(arb, true))
+}
+
+// recordEndEvent records the end of a contact that recorded a begin event.
+func (space *Space) recordEndEvent(arb *Arbiter, removed bool) {
+	if !space.events.enabled || !arb.eventBegun {
+		return
+	}
+
+	arb.eventBegun = false
+	if arb.a.sensor || arb.b.sensor {
+		space.events.sensor = append(space.events.sensor, sensorEvent(arb, false))
+		return
+	}
+
+	a, b := arb.Shapes()
+	space.events.end = append(space.events.end, ContactEndEvent{
+		ShapeA: a, ShapeB: b,
+		BodyA: a.body, BodyB: b.body,
+		Removed: removed,
+	})
+}
+
+func sensorEvent(arb *Arbiter, begin bool) SensorEvent {
+	sensor, visitor := arb.a, arb.b
+	if !sensor.sensor {
+		sensor, visitor = visitor, sensor
+	}
+	return SensorEvent{
+		Sensor: sensor, Visitor: visitor,
+		SensorBody: sensor.body, VisitorBody: visitor.body,
+		Begin: begin,
+	}
+}
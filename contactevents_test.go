@@ -0,0 +1,331 @@
+package cp
+
+import "testing"
+
+func newEventSpace() (*Space, *Shape, *Body) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	space.SetContactEvents(true)
+	ground := space.AddShape(NewSegment(space.StaticBody, Vector{-100, 0}, Vector{100, 0}, 0))
+
+	ball := space.AddBody(NewBody(1, MomentForCircle(1, 0, 5, Vector{})))
+	ball.SetPosition(Vector{0, 50})
+	space.AddShape(NewCircle(ball, 5, Vector{}))
+	return space, ground, ball
+}
+
+func TestSpace_ContactEvents(t *testing.T) {
+	space, ground, ball := newEventSpace()
+
+	var begins []ContactBeginEvent
+	var hits []ContactHitEvent
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+		begins = append(begins, space.ContactBeginEvents()...)
+		hits = append(hits, space.HitEvents()...)
+	}
+
+	if len(begins) != 1 {
+		t.Fatalf("expected 1 begin event, got %v", len(begins))
+	}
+	begin := begins[0]
+	if begin.BodyA != ball && begin.BodyB != ball || begin.ShapeA != ground && begin.ShapeB != ground {
+		t.Errorf("expected the event to be between the ball and the ground, got %v", begin)
+	}
+	if begin.Points.Count != 1 || begin.Impulse == (Vector{}) {
+		t.Errorf("expected a contact point and an impulse, got %v", begin)
+	}
+
+	// The ball lands at about 90 units per second, and only the landing is a hit.
+	if len(hits) != 1 {
+		t.Fatalf("expected 1 hit event, got %v", len(hits))
+	}
+	if speed := hits[0].ApproachSpeed; speed < 80 || speed > 100 {
+		t.Errorf("expected an approach speed of about 90, got %v", speed)
+	}
+	if p := hits[0].Point; p.Distance(Vector{0, 0}) > 0.5 {
+		t.Errorf("expected the hit under the ball, got %v", p)
+	}
+	if len(space.ContactEndEvents()) != 0 {
+		t.Error("expected no end events while the ball rests on the ground")
+	}
+
+	// Lift the ball off the ground.
+	ball.SetPosition(Vector{0, 50})
+	ball.SetVelocity(0, 0)
+	space.Step(1.0 / 60.0)
+	ends := space.ContactEndEvents()
+	if len(ends) != 1 || ends[0].Removed {
+		t.Fatalf("expected the contact to end, got %v", ends)
+	}
+
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if len(space.ContactBeginEvents()) != 1 {
+		t.Error("expected the events to accumulate until they are read")
+	}
+	space.RemoveShape(ground)
+	if ends := space.ContactEndEvents(); len(ends) != 1 || !ends[0].Removed {
+		t.Errorf("expected removing the ground to end the contact, got %v", ends)
+	}
+}
+
+func TestSpace_ContactEventsNearMiss(t *testing.T) {
+	space, ground, ball := newEventSpace()
+	space.SetGravity(Vector{})
+	space.SetSpeculativeContacts(true)
+	space.RemoveShape(ground)
+	space.AddShape(NewCircle(space.StaticBody, 5, Vector{}))
+
+	// The ball passes diagonally 3 units from the post, close enough for speculative contacts but never touching it.
+	ball.SetPosition(Vector{-40.8, 59.2})
+	ball.SetVelocity(300, -300)
+	contacts := 0
+	for i := 0; i < 20; i++ {
+		space.Step(1.0 / 60.0)
+		contacts += len(space.arbiters)
+	}
+
+	if contacts == 0 {
+		t.Fatal("expected the ball to get speculative contacts")
+	}
+	if begins, ends := space.ContactBeginEvents(), space.ContactEndEvents(); len(begins) != 0 || len(ends) != 0 {
+		t.Errorf("expected no events for shapes that didn't touch, got %v and %v", begins, ends)
+	}
+	if v := ball.Velocity(); !v.Near(Vector{300, -300}, 1e-6) {
+		t.Errorf("expected the ball to pass the post untouched, got a velocity of %v", v)
+	}
+}
+
+func TestSpace_ContactEventsDisabled(t *testing.T) {
+	space, _, _ := newEventSpace()
+	space.SetContactEvents(false)
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if len(space.ContactBeginEvents()) != 0 || len(space.HitEvents()) != 0 {
+		t.Error("expected no events to be recorded")
+	}
+
+	// Contacts that began while disabled don't record end events.
+	space.SetContactEvents(true)
+	for i := 0; i < 10; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if begins := space.ContactBeginEvents(); len(begins) != 1 {
+		t.Errorf("expected an ongoing contact to begin once enabled, got %v", len(begins))
+	}
+}
+
+func TestSpace_ContactEventsToggledAsleep(t *testing.T) {
+	space, _, ball := newEventSpace()
+	space.SleepTimeThreshold = 0.25
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if !ball.IsSleeping() || len(space.ContactBeginEvents()) != 1 {
+		t.Fatal("expected the ball to land and fall asleep")
+	}
+
+	space.SetContactEvents(false)
+	space.SetContactEvents(true)
+
+	// Lift the ball off the ground. The contact began while events were disabled, so it doesn't end either.
+	ball.SetPosition(Vector{0, 50})
+	for i := 0; i < 10; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if ends := space.ContactEndEvents(); len(ends) != 0 {
+		t.Errorf("expected no end events without a begin event, got %v", ends)
+	}
+}
+
+func TestSpace_HitEventThreshold(t *testing.T) {
+	space, _, _ := newEventSpace()
+	space.SetHitEventThreshold(200)
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if hits := space.HitEvents(); len(hits) != 0 {
+		t.Errorf("expected the landing to be below the threshold, got %v", hits)
+	}
+}
+
+func TestSpace_SensorEvents(t *testing.T) {
+	space, ground, ball := newEventSpace()
+	space.RemoveShape(ground)
+	sensor := space.AddShape(NewBox(space.StaticBody, 20, 20, 0))
+	sensor.SetSensor(true)
+
+	var events []SensorEvent
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+		events = append(events, space.SensorEvents()...)
+	}
+
+	// The ball falls through the sensor.
+	if len(events) != 2 {
+		t.Fatalf("expected 2 sensor events, got %v", events)
+	}
+	if !events[0].Begin || events[1].Begin {
+		t.Error("expected the ball to enter the sensor and leave it")
+	}
+	for _, event := range events {
+		if event.Sensor != sensor || event.VisitorBody != ball {
+			t.Errorf("expected the ball to visit the sensor, got %v", event)
+		}
+	}
+	if len(space.ContactBeginEvents()) != 0 {
+		t.Error("expected sensors to only record sensor events")
+	}
+}
+
+func TestSpace_SensorOverlaps(t *testing.T) {
+	space, ground, ball := newEventSpace()
+	space.SetContactEvents(false)
+	space.RemoveShape(ground)
+	sensor := space.AddShape(NewBox(space.StaticBody, 20, 20, 0))
+	sensor.SetSensor(true)
+	var ballShape *Shape
+	ball.EachShape(func(shape *Shape) { ballShape = shape })
+
+	overlapped := false
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+
+		overlaps := space.SensorOverlaps(sensor)
+		inside := ball.Position().Y < 15 && ball.Position().Y > -15
+		if inside && (len(overlaps) != 1 || overlaps[0] != ballShape) {
+			t.Fatalf("expected the ball to overlap the sensor at %v, got %v", ball.Position(), overlaps)
+		}
+		if !inside && len(overlaps) != 0 {
+			t.Fatalf("expected no overlaps at %v, got %v", ball.Position(), overlaps)
+		}
+		if inside {
+			overlapped = true
+			if sensors := space.SensorOverlaps(ballShape); len(sensors) != 1 || sensors[0] != sensor {
+				t.Fatalf("expected the ball to be in the sensor, got %v", sensors)
+			}
+		}
+	}
+	if !overlapped {
+		t.Error("expected the ball to pass through the sensor")
+	}
+}
+
+func TestSpace_SensorOverlapsRemoved(t *testing.T) {
+	space, ground, ball := newEventSpace()
+	space.RemoveShape(ground)
+	sensor := space.AddShape(NewBox(space.StaticBody, 20, 20, 0))
+	sensor.SetSensor(true)
+	ball.SetPosition(Vector{})
+	var ballShape *Shape
+	ball.EachShape(func(shape *Shape) { ballShape = shape })
+
+	space.Step(1.0 / 60.0)
+	if overlaps := space.SensorOverlaps(sensor); len(overlaps) != 1 {
+		t.Fatalf("expected the ball to overlap the sensor, got %v", overlaps)
+	}
+
+	sensor.SetSensor(false)
+	space.Step(1.0 / 60.0)
+	if overlaps := space.SensorOverlaps(ballShape); len(overlaps) != 0 {
+		t.Errorf("expected no overlaps once the shape isn't a sensor, got %v", overlaps)
+	}
+
+	sensor.SetSensor(true)
+	space.Step(1.0 / 60.0)
+	if overlaps := space.SensorOverlaps(ballShape); len(overlaps) != 1 {
+		t.Fatalf("expected the ball to overlap the sensor again, got %v", overlaps)
+	}
+	space.RemoveShape(ballShape)
+	if overlaps := space.SensorOverlaps(sensor); len(overlaps) != 0 {
+		t.Errorf("expected removing the ball to remove its overlap, got %v", overlaps)
+	}
+}
+
+func TestSpace_SensorEnterExitEvents(t *testing.T) {
+	space, ground, ball := newEventSpace()
+	space.RemoveShape(ground)
+	sensor := space.AddShape(NewBox(space.StaticBody, 20, 20, 0))
+	sensor.SetSensor(true)
+
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	exits := space.SensorExitEvents()
+	if len(exits) != 1 || exits[0].Begin || exits[0].VisitorBody != ball {
+		t.Errorf("expected the ball to exit the sensor, got %v", exits)
+	}
+	enters := space.SensorEnterEvents()
+	if len(enters) != 1 || !enters[0].Begin || enters[0].Sensor != sensor {
+		t.Errorf("expected the ball to enter the sensor, got %v", enters)
+	}
+	if events := space.SensorEvents(); len(events) != 0 {
+		t.Errorf("expected the events to be read, got %v", events)
+	}
+}
+
+func TestShape_SensorOverlapOnly(t *testing.T) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	sensor := space.AddShape(NewBox(space.StaticBody, 20, 20, 0))
+	sensor.SetSensor(true)
+	sensor.SetSensorOverlapOnly(true)
+
+	box := space.AddBody(NewBody(1, MomentForBox(1, 10, 10)))
+	box.SetPosition(Vector{0, 20})
+	space.AddShape(NewBox(box, 10, 10, 0))
+
+	counts := map[int]int{}
+	handler := space.NewCollisionHandler(0, 0)
+	handler.PreSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) bool {
+		counts[arb.ContactPointSet().Count]++
+		return true
+	}
+
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if len(counts) != 1 || counts[1] == 0 {
+		t.Errorf("expected a single contact point while overlapping, got %v", counts)
+	}
+
+	// Boxes overlapping the full sensor normally have two contact points.
+	sensor.SetSensorOverlapOnly(false)
+	box.SetPosition(Vector{0, 20})
+	box.SetVelocity(0, 0)
+	counts = map[int]int{}
+	for i := 0; i < 30; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if counts[2] == 0 {
+		t.Errorf("expected two contact points without the flag, got %v", counts)
+	}
+}
+
+func TestSpace_HitEventImpulseThreshold(t *testing.T) {
+	space, _, ball := newEventSpace()
+	space.SetHitEventThreshold(0)
+	space.SetHitEventImpulseThreshold(10)
+
+	var hits []ContactHitEvent
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+		hits = append(hits, space.HitEvents()...)
+	}
+
+	// Only the landing is strong enough, not resting on the ground.
+	if len(hits) != 1 {
+		t.Fatalf("expected 1 hit event, got %v", len(hits))
+	}
+	hit := hits[0]
+	if hit.BodyA != ball && hit.BodyB != ball {
+		t.Errorf("expected the ball to hit the ground, got %v", hit)
+	}
+	if hit.MaxImpulse < 90 || hit.ApproachSpeed < 90 || hit.KineticEnergy <= 0 {
+		t.Errorf("expected a hard hit, got %v", hit)
+	}
+}
@@ -87,25 +87,38 @@ type Contact struct {
 
 	nMass, tMass float64
 	bounce       float64 // TODO: look for an alternate bounce solution
+	approach     float64 // normal speed the shapes approached at before the solver ran
+
+	// the arbiter's restitution, friction and tangent surface speed, modifiable per contact
+	e, u         float64
+	surfaceSpeed float64
+	disabled     bool
 
 	jnAcc, jtAcc, jBias float64
 	bias                float64
+	jnMax               float64 // largest normal impulse the solver applied in the step
 
 	hash HashValue
 }
 
 func (c *Contact) Clone() Contact {
 	return Contact{
-		r1:     c.r1,
-		r2:     c.r2,
-		nMass:  c.nMass,
-		tMass:  c.tMass,
-		bounce: c.bounce,
-		jnAcc:  c.jnAcc,
-		jtAcc:  c.jtAcc,
-		jBias:  c.jBias,
-		bias:   c.bias,
-		hash:   c.hash,
+		r1:           c.r1,
+		r2:           c.r2,
+		nMass:        c.nMass,
+		tMass:        c.tMass,
+		bounce:       c.bounce,
+		approach:     c.approach,
+		e:            c.e,
+		u:            c.u,
+		surfaceSpeed: c.surfaceSpeed,
+		disabled:     c.disabled,
+		jnAcc:        c.jnAcc,
+		jnMax:        c.jnMax,
+		jtAcc:        c.jtAcc,
+		jBias:        c.jBias,
+		bias:         c.bias,
+		hash:         c.hash,
 	}
 }
 
@@ -114,6 +127,11 @@ type CollisionInfo struct {
 	a, b        *Shape
 	collisionId uint32
 
+	// shapes separated by less than this generate speculative contacts
+	margin float64
+	// only report that the shapes overlap, with the closest points as the single contact
+	overlapOnly bool
+
 	n     Vector
 	count int
 	arr   []Contact
@@ -130,6 +148,12 @@ func (info *CollisionInfo) PushContact(p1, p2 Vector, hash HashValue) {
 	info.count++
 }
 
+// pushClosestPoints pushes the closest points between shapes with radii r1 and r2 as a single contact.
+func (info *CollisionInfo) pushClosestPoints(points ClosestPoints, r1, r2 float64) {
+	info.n = points.n
+	info.PushContact(points.a.Add(points.n.Mult(r1)), points.b.Add(points.n.Mult(-r2)), 0)
+}
+
 // ShapeMassInfo is mass info struct
 type ShapeMassInfo struct {
 	m, i, area float64
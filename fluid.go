@@ -0,0 +1,170 @@
+package cp
+
+import "math"
+
+// Number of vertices used to approximate circles and segment end caps when clipping them against a fluid.
+const fluidCircleVerts = 32
+
+// FluidVolume is a sensor shape that applies buoyancy and drag to the bodies overlapping it.
+//
+// The fluid fills its shape, which can be a polygon, circle or segment with a radius. The rounding radius of polygons is ignored.
+// Buoyancy pushes against the gravity acting on each body, including its gravity scale and the space's gravity fields.
+type FluidVolume struct {
+	// Density is the mass per unit area of the fluid.
+	Density float64
+	// LinearDrag slows down bodies moving through the fluid.
+	LinearDrag float64
+	// AngularDrag slows down bodies spinning in the fluid.
+	AngularDrag float64
+
+	shape *Shape
+}
+
+// AddFluidVolume turns shape into a fluid volume and adds it to the space if it isn't already.
+//
+// The shape is made a sensor and given collisionType, and FluidPreSolve is added to the handler chain of the wildcard handler for collisionType.
+// Handlers already set for the type are still called. Any number of fluid volumes can share the type.
+func (space *Space) AddFluidVolume(shape *Shape, collisionType CollisionType, density, drag float64) *FluidVolume {
+	fluid := &FluidVolume{
+		Density:     density,
+		LinearDrag:  drag,
+		AngularDrag: drag,
+		shape:       shape,
+	}
+
+	shape.SetSensor(true)
+	shape.SetCollisionType(collisionType)
+	shape.fluid = fluid
+	if !space.ContainsShape(shape) {
+		space.AddShape(shape)
+	}
+
+	if handler := space.fluidHandlers[collisionType]; handler == nil || handler.removed {
+		if space.fluidHandlers == nil {
+			space.fluidHandlers = map[CollisionType]*PriorityHandler{}
+		}
+		handler = space.AddWildcardHandler(collisionType, 0)
+		handler.PreSolveFunc = FluidPreSolve
+		space.fluidHandlers[collisionType] = handler
+	}
+	return fluid
+}
+
+// Shape returns the sensor shape of the fluid volume.
+func (fluid *FluidVolume) Shape() *Shape {
+	return fluid.shape
+}
+
+// FluidPreSolve is the pre-solve function installed for fluid volumes. It applies buoyancy and drag to the other shape's body.
+func FluidPreSolve(arb *Arbiter, space *Space, _ interface{}) bool {
+	fluidShape, shape := arb.Shapes()
+	fluid := fluidShape.fluid
+	if fluid == nil || shape.fluid != nil || shape.sensor {
+		return true
+	}
+
+	body := shape.body
+	if body.GetType() != BODY_DYNAMIC {
+		return true
+	}
+
+	clipped := fluid.clip(shape)
+	if len(clipped) < 3 {
+		return true
+	}
+
+	area := math.Abs(AreaForPoly(len(clipped), clipped, 0))
+	centroid := CentroidForPoly(len(clipped), clipped)
+	dt := space.TimeStep()
+
+	// Apply the buoyancy force as an impulse.
+	displacedMass := area * fluid.Density
+	body.ApplyImpulseAtWorldPoint(body.Gravity(space.Gravity()).Mult(-displacedMass*dt), centroid)
+
+	// Apply linear damping for the fluid drag.
+	vCentroid := body.VelocityAtWorldPoint(centroid)
+	k := k_scalar_body(body, centroid.Sub(body.p), vCentroid.Normalize())
+	damping := area * fluid.LinearDrag * fluid.Density
+	vCoef := math.Exp(-damping * dt * k)
+	body.ApplyImpulseAtWorldPoint(vCentroid.Mult(vCoef).Sub(vCentroid).Mult(1/k), centroid)
+
+	// Apply angular damping for the fluid drag.
+	wDamping := MomentForPoly(fluid.AngularDrag*fluid.Density*area, len(clipped), clipped, body.p.Neg(), 0)
+	body.w *= math.Exp(-wDamping * dt * body.i_inv)
+
+	return true
+}
+
+// clip returns the part of the shape inside the fluid as a polygon in world coordinates.
+func (fluid *FluidVolume) clip(shape *Shape) []Vector {
+	outline := shapeOutline(fluid.shape)
+	if len(outline) < 3 {
+		return nil
+	}
+
+	// The outline is convex and counter-clockwise, so clip against the outside of each edge.
+	verts := shapeOutline(shape)
+	for i, j := 0, len(outline)-1; i < len(outline); j, i = i, i+1 {
+		n := outline[i].Sub(outline[j]).ReversePerp().Normalize()
+		verts = clipPoly(verts, n, outline[j].Dot(n))
+	}
+	return verts
+}
+
+// shapeOutline approximates a shape with a polygon in world coordinates.
+func shapeOutline(shape *Shape) []Vector {
+	switch class := shape.Class.(type) {
+	case *Circle:
+		return arcVerts(nil, class.tc, class.r, Vector{1, 0}, fluidCircleVerts)
+	case *Segment:
+		if class.r == 0 {
+			return nil
+		}
+		// Sweep around the end cap at b and then the one at a.
+		n := class.tb.Sub(class.ta).Normalize().Perp()
+		verts := arcVerts(nil, class.tb, class.r, n.Neg(), fluidCircleVerts/2+1)
+		return arcVerts(verts, class.ta, class.r, n, fluidCircleVerts/2+1)
+	case *PolyShape:
+		// The rounding radius of polygons is ignored.
+		verts := make([]Vector, class.count)
+		for i := range verts {
+			verts[i] = class.planes[i].v0
+		}
+		return verts
+	default:
+		return nil
+	}
+}
+
+// arcVerts appends count vertices of a regular fluidCircleVerts-gon around center, going counter-clockwise from dir.
+//
+// The radius is scaled up so the full polygon has the same area as the circle.
+func arcVerts(verts []Vector, center Vector, r float64, dir Vector, count int) []Vector {
+	step := 2 * math.Pi / fluidCircleVerts
+	r *= math.Sqrt(step / math.Sin(step))
+	for i := 0; i < count; i++ {
+		verts = append(verts, center.Add(dir.Rotate(ForAngle(step*float64(i))).Mult(r)))
+	}
+	return verts
+}
+
+// clipPoly clips a polygon against a plane, keeping the part where p.Dot(n) <= d.
+func clipPoly(verts []Vector, n Vector, d float64) []Vector {
+	count := len(verts)
+	clipped := make([]Vector, 0, count+1)
+	for i, j := 0, count-1; i < count; j, i = i, i+1 {
+		a := verts[j]
+		b := verts[i]
+
+		aLevel := a.Dot(n) - d
+		bLevel := b.Dot(n) - d
+		if aLevel <= 0 {
+			clipped = append(clipped, a)
+		}
+		if aLevel*bLevel < 0 {
+			t := math.Abs(aLevel) / (math.Abs(aLevel) + math.Abs(bLevel))
+			clipped = append(clipped, a.Lerp(b, t))
+		}
+	}
+	return clipped
+}
@@ -0,0 +1,121 @@
+package cp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpace_AddFluidVolume(t *testing.T) {
+	const fluidType CollisionType = 1
+
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	space.AddFluidVolume(NewBox2(space.StaticBody, NewBB(-50, -50, 50, 0), 0), fluidType, 1, 2)
+
+	// A box half as dense as the fluid should float half submerged.
+	body := space.AddBody(NewBody(0, 0))
+	box := space.AddShape(NewBox(body, 10, 10, 0))
+	box.SetDensity(0.5)
+	body.SetPosition(Vector{0, 10})
+
+	for i := 0; i < 600; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	if y := body.Position().Y; math.Abs(y) > 0.5 {
+		t.Errorf("expected box to float with its center at the surface, got %v", y)
+	}
+}
+
+func TestFluidVolume_Shape(t *testing.T) {
+	const fluidType CollisionType = 1
+
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	space.AddFluidVolume(NewCircle(space.StaticBody, 50, Vector{}), fluidType, 1, 0)
+
+	// The corner of the circle's bounding box is outside of the fluid.
+	corner := space.AddBody(NewBody(0, 0))
+	space.AddShape(NewCircle(corner, 2, Vector{})).SetDensity(0.5)
+	corner.SetPosition(Vector{45, 45})
+	center := space.AddBody(NewBody(0, 0))
+	space.AddShape(NewCircle(center, 2, Vector{})).SetDensity(0.5)
+
+	space.Step(1.0 / 60.0)
+	if v := corner.Velocity(); v.Y >= 0 {
+		t.Errorf("expected the body outside of the fluid to fall, got %v", v)
+	}
+	if v := center.Velocity(); v.Y <= 0 {
+		t.Errorf("expected the body in the fluid to rise, got %v", v)
+	}
+}
+
+func TestFluidVolume_ChainsHandler(t *testing.T) {
+	const fluidType CollisionType = 1
+
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	splashes := 0
+	space.NewWildcardCollisionHandler(fluidType).PreSolveFunc = func(arb *Arbiter, space *Space, userData interface{}) bool {
+		splashes++
+		return true
+	}
+	space.AddFluidVolume(NewBox2(space.StaticBody, NewBB(-50, -50, 50, 0), 0), fluidType, 1, 0)
+	space.AddFluidVolume(NewBox2(space.StaticBody, NewBB(-50, -100, 50, -50), 0), fluidType, 1, 0)
+
+	body := space.AddBody(NewBody(0, 0))
+	space.AddShape(NewBox(body, 10, 10, 0)).SetDensity(0.5)
+	body.SetPosition(Vector{0, -20})
+
+	space.Step(1.0 / 60.0)
+	if splashes != 1 {
+		t.Errorf("expected the existing pre-solve function to be called, got %v calls", splashes)
+	}
+	// Buoyancy is applied once, even though two fluids share the type.
+	if v := body.Velocity().Y; math.Abs(v-100.0/60.0) > 1e-6 {
+		t.Errorf("expected buoyancy to be applied once, got velocity %v", v)
+	}
+}
+
+func TestFluidVolume_GravityScale(t *testing.T) {
+	const fluidType CollisionType = 1
+
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	space.AddFluidVolume(NewBox2(space.StaticBody, NewBB(-50, -50, 50, 0), 0), fluidType, 1, 0)
+
+	weightless := space.AddBody(NewBody(0, 0))
+	space.AddShape(NewBox(weightless, 10, 10, 0)).SetDensity(0.5)
+	weightless.SetPosition(Vector{-20, -20})
+	weightless.SetGravityScale(0)
+
+	heavy := space.AddBody(NewBody(0, 0))
+	space.AddShape(NewBox(heavy, 10, 10, 0)).SetDensity(0.5)
+	heavy.SetPosition(Vector{20, -20})
+	heavy.SetGravityScale(2)
+
+	space.Step(1.0 / 60.0)
+	if v := weightless.Velocity(); v != (Vector{}) {
+		t.Errorf("expected no buoyancy without gravity, got velocity %v", v)
+	}
+	// Twice the gravity and twice the buoyancy, which is twice the weight of the half as dense box.
+	if v := heavy.Velocity().Y; math.Abs(v-200.0/60.0) > 1e-6 {
+		t.Errorf("expected buoyancy against the scaled gravity, got velocity %v", v)
+	}
+}
+
+func TestClipPoly(t *testing.T) {
+	verts := []Vector{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}}
+	clipped := clipPoly(verts, Vector{0, 1}, 0)
+	if area := AreaForPoly(len(clipped), clipped, 0); area != 2 {
+		t.Errorf("expected area 2, got %v", area)
+	}
+	if c := CentroidForPoly(len(clipped), clipped); !c.Near(Vector{0, -0.5}, 1e-9) {
+		t.Errorf("expected centroid (0, -0.5), got %v", c)
+	}
+
+	circle := arcVerts(nil, Vector{}, 1, Vector{1, 0}, fluidCircleVerts)
+	if area := AreaForPoly(len(circle), circle, 0); math.Abs(area-math.Pi) > 1e-9 {
+		t.Errorf("expected circle area to be preserved, got %v", area)
+	}
+}
@@ -0,0 +1,60 @@
+package cp
+
+import "math"
+
+// GravityField adds gravity that varies over a space, such as the pull of a planet.
+// Fields are added to a space with Space.AddGravityField, and their gravity is added to the space's gravity for each body.
+type GravityField interface {
+	// Gravity returns the acceleration the field applies to the body.
+	Gravity(body *Body) Vector
+}
+
+// PointGravity pulls bodies towards a point with an inverse square falloff, like a planet seen from orbit.
+type PointGravity struct {
+	Point Vector
+	// Strength is the acceleration at a distance of 1.
+	Strength float64
+	// MinRadius limits the acceleration of bodies close to the point, which is otherwise unbounded.
+	MinRadius float64
+}
+
+func (field *PointGravity) Gravity(body *Body) Vector {
+	delta := field.Point.Sub(body.p)
+	distSq := math.Max(delta.LengthSq(), field.MinRadius*field.MinRadius)
+	if distSq == 0 {
+		return Vector{}
+	}
+
+	return delta.Mult(field.Strength / (distSq * math.Sqrt(distSq)))
+}
+
+// RadialGravity pulls bodies towards a point with the same acceleration at any distance, like walking on a small planet.
+type RadialGravity struct {
+	Center   Vector
+	Strength float64
+	// Radius limits the field to bodies within that distance of the center, or 0 for no limit.
+	Radius float64
+}
+
+func (field *RadialGravity) Gravity(body *Body) Vector {
+	delta := field.Center.Sub(body.p)
+	dist := delta.Length()
+	if dist == 0 || (field.Radius > 0 && dist > field.Radius) {
+		return Vector{}
+	}
+
+	return delta.Mult(field.Strength / dist)
+}
+
+// RegionGravity applies a constant acceleration to bodies whose center of gravity is inside a bounding box, such as an updraft or a gravity flipping zone.
+type RegionGravity struct {
+	BB           BB
+	Acceleration Vector
+}
+
+func (field *RegionGravity) Gravity(body *Body) Vector {
+	if field.BB.ContainsVect(body.p) {
+		return field.Acceleration
+	}
+	return Vector{}
+}
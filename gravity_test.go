@@ -0,0 +1,127 @@
+package cp
+
+import (
+	"math"
+	"testing"
+)
+
+func newFallingBody(space *Space, p Vector) *Body {
+	body := space.AddBody(NewBody(1, MomentForCircle(1, 0, 1, Vector{})))
+	body.SetPosition(p)
+	space.AddShape(NewCircle(body, 1, Vector{}))
+	return body
+}
+
+func TestBody_GravityScale(t *testing.T) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+
+	normal := newFallingBody(space, Vector{0, 0})
+	floating := newFallingBody(space, Vector{10, 0})
+	floating.SetGravityScale(0)
+	heavy := newFallingBody(space, Vector{20, 0})
+	heavy.SetGravityScale(2)
+
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	if v := normal.Velocity(); math.Abs(v.Y+100) > 1e-9 {
+		t.Errorf("expected velocity -100, got %v", v.Y)
+	}
+	if v := floating.Velocity(); v.Y != 0 {
+		t.Errorf("expected the body to float, got velocity %v", v.Y)
+	}
+	if v := heavy.Velocity(); math.Abs(v.Y+200) > 1e-9 {
+		t.Errorf("expected velocity -200, got %v", v.Y)
+	}
+}
+
+func TestGravityFields(t *testing.T) {
+	body := NewBody(1, 1)
+	body.SetPosition(Vector{10, 0})
+
+	point := &PointGravity{Point: Vector{}, Strength: 1000, MinRadius: 5}
+	if g := point.Gravity(body); g.Sub(Vector{-10, 0}).Length() > 1e-9 {
+		t.Errorf("expected point gravity (-10, 0), got %v", g)
+	}
+	body.SetPosition(Vector{0, 1})
+	if g := point.Gravity(body); g.Sub(Vector{0, -8}).Length() > 1e-9 {
+		t.Errorf("expected point gravity to be limited to (0, -8), got %v", g)
+	}
+
+	radial := &RadialGravity{Center: Vector{}, Strength: 10, Radius: 50}
+	body.SetPosition(Vector{0, 40})
+	if g := radial.Gravity(body); g.Sub(Vector{0, -10}).Length() > 1e-9 {
+		t.Errorf("expected radial gravity (0, -10), got %v", g)
+	}
+	body.SetPosition(Vector{0, 60})
+	if g := radial.Gravity(body); g != (Vector{}) {
+		t.Errorf("expected no radial gravity outside of the radius, got %v", g)
+	}
+
+	region := &RegionGravity{BB: NewBB(0, 0, 10, 10), Acceleration: Vector{0, 10}}
+	body.SetPosition(Vector{5, 5})
+	if g := region.Gravity(body); g != (Vector{0, 10}) {
+		t.Errorf("expected region gravity (0, 10), got %v", g)
+	}
+	body.SetPosition(Vector{15, 5})
+	if g := region.Gravity(body); g != (Vector{}) {
+		t.Errorf("expected no region gravity outside of the region, got %v", g)
+	}
+}
+
+func TestSpace_AddGravityField(t *testing.T) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	space.SleepTimeThreshold = 0.5
+
+	ground := space.AddShape(NewSegment(space.StaticBody, Vector{-100, 0}, Vector{100, 0}, 0))
+	ground.SetFriction(1)
+	resting := newFallingBody(space, Vector{0, 1})
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if !resting.IsSleeping() {
+		t.Fatal("expected the body to fall asleep")
+	}
+
+	// An updraft cancels out gravity and lifts the body.
+	updraft := space.AddGravityField(&RegionGravity{BB: NewBB(-10, -10, 10, 10), Acceleration: Vector{0, 200}})
+	if resting.IsSleeping() {
+		t.Error("expected adding a field to wake the body")
+	}
+	for i := 0; i < 30; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if v := resting.Velocity(); v.Y <= 0 {
+		t.Errorf("expected the body to rise, got velocity %v", v.Y)
+	}
+
+	space.RemoveGravityField(updraft)
+	if len(space.GravityFields()) != 0 {
+		t.Error("expected the field to be removed")
+	}
+	for i := 0; i < 30; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if v := resting.Velocity(); v.Y >= 0 {
+		t.Errorf("expected the body to fall, got velocity %v", v.Y)
+	}
+}
+
+func TestSpace_PlanetOrbit(t *testing.T) {
+	space := NewSpace()
+	space.AddGravityField(&PointGravity{Strength: 1e6})
+
+	// A circular orbit has speed sqrt(strength / radius).
+	moon := newFallingBody(space, Vector{100, 0})
+	moon.SetVelocity(0, 100)
+
+	for i := 0; i < 600; i++ {
+		space.Step(1.0 / 120.0)
+		if r := moon.Position().Length(); math.Abs(r-100) > 2 {
+			t.Fatalf("expected the orbit to stay circular, got radius %v", r)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package cp
+
+import "math"
+
+// Grid is a dense uniform grid spatial index with fixed world bounds.
+//
+// Every cell keeps a list of the objects that overlap it, so inserting an object no larger than a cell is constant time.
+// It works best for tile based worlds where most objects are about the same size.
+// Objects outside of the bounds are kept in the nearest edge cells. They still collide correctly, but more slowly.
+type Grid struct {
+	*SpatialIndex
+
+	bounds     BB
+	cellSize   float64
+	cols, rows int
+
+	cells     [][]*gridHandle
+	handleSet *HashSet[*Shape, *gridHandle]
+
+	stamp uint
+}
+
+type gridHandle struct {
+	obj *Shape
+	bb  BB
+
+	// range of cells the object is stored in
+	l, b, r, t int
+
+	stamp uint
+	seq   int
+}
+
+func NewGrid(cellSize float64, bounds BB, bbfunc SpatialIndexBB, staticIndex *SpatialIndex) *SpatialIndex {
+	assert(cellSize > 0, "Grid cell size must be positive.")
+
+	cols := int(math.Ceil((bounds.R - bounds.L) / cellSize))
+	if cols < 1 {
+		cols = 1
+	}
+	rows := int(math.Ceil((bounds.T - bounds.B) / cellSize))
+	if rows < 1 {
+		rows = 1
+	}
+
+	grid := &Grid{
+		bounds:   bounds,
+		cellSize: cellSize,
+		cols:     cols,
+		rows:     rows,
+		cells:    make([][]*gridHandle, cols*rows),
+		handleSet: NewHashSet[*Shape, *gridHandle](func(obj *Shape, elt *gridHandle) bool {
+			return obj == elt.obj
+		}),
+		stamp: 1,
+	}
+	spatialIndex := NewSpatialIndex(grid, bbfunc, staticIndex)
+	grid.SpatialIndex = spatialIndex
+	return spatialIndex
+}
+
+// cellX returns the column of the cell x falls in, clamped to the grid.
+// The clamping is done before converting to int, since infinite coordinates don't convert.
+func (grid *Grid) cellX(x float64) int {
+	return clampCell((x-grid.bounds.L)/grid.cellSize, grid.cols)
+}
+
+// cellY returns the row of the cell y falls in, clamped to the grid.
+func (grid *Grid) cellY(y float64) int {
+	return clampCell((y-grid.bounds.B)/grid.cellSize, grid.rows)
+}
+
+func clampCell(f float64, count int) int {
+	if !(f >= 0) {
+		// Also catches NaN.
+		return 0
+	} else if f >= float64(count) {
+		return count - 1
+	}
+	return int(f)
+}
+
+func (grid *Grid) addHandle(hand *gridHandle) {
+	for j := hand.b; j <= hand.t; j++ {
+		for i := hand.l; i <= hand.r; i++ {
+			idx := j*grid.cols + i
+			grid.cells[idx] = append(grid.cells[idx], hand)
+		}
+	}
+}
+
+func (grid *Grid) removeHandle(hand *gridHandle) {
+	for j := hand.b; j <= hand.t; j++ {
+		for i := hand.l; i <= hand.r; i++ {
+			idx := j*grid.cols + i
+			cell := grid.cells[idx]
+			for k, other := range cell {
+				if other == hand {
+					// leak-free delete from slice
+					last := len(cell) - 1
+					cell[k] = cell[last]
+					cell[last] = nil
+					grid.cells[idx] = cell[:last]
+					break
+				}
+			}
+		}
+	}
+}
+
+// updateHandle refreshes the handle's bounding box and moves it if the range of cells it covers changed.
+func (grid *Grid) updateHandle(hand *gridHandle) {
+	bb := grid.bbfunc(hand.obj)
+	hand.bb = bb
+
+	l, r := grid.cellX(bb.L), grid.cellX(bb.R)
+	b, t := grid.cellY(bb.B), grid.cellY(bb.T)
+	if l == hand.l && r == hand.r && b == hand.b && t == hand.t {
+		return
+	}
+
+	grid.removeHandle(hand)
+	hand.l, hand.r, hand.b, hand.t = l, r, b, t
+	grid.addHandle(hand)
+}
+
+func (grid *Grid) Count() int {
+	return int(grid.handleSet.Count())
+}
+
+func (grid *Grid) Each(f SpatialIndexIterator) {
+	grid.handleSet.Each(func(hand *gridHandle) {
+		f(hand.obj)
+	})
+}
+
+func (grid *Grid) Contains(obj *Shape, hashId HashValue) bool {
+	return grid.handleSet.Find(hashId, obj) != nil
+}
+
+func (grid *Grid) Insert(obj *Shape, hashId HashValue) {
+	grid.handleSet.Insert(hashId, obj, func(obj *Shape) *gridHandle {
+		bb := grid.bbfunc(obj)
+		hand := &gridHandle{
+			obj: obj,
+			bb:  bb,
+			l:   grid.cellX(bb.L),
+			r:   grid.cellX(bb.R),
+			b:   grid.cellY(bb.B),
+			t:   grid.cellY(bb.T),
+		}
+		grid.addHandle(hand)
+		return hand
+	})
+}
+
+func (grid *Grid) Remove(obj *Shape, hashId HashValue) {
+	hand := grid.handleSet.Remove(hashId, obj)
+	if hand != nil {
+		grid.removeHandle(hand)
+	}
+}
+
+func (grid *Grid) Reindex() {
+	grid.handleSet.Each(func(hand *gridHandle) {
+		grid.updateHandle(hand)
+	})
+}
+
+func (grid *Grid) ReindexObject(obj *Shape, hashId HashValue) {
+	hand := grid.handleSet.Find(hashId, obj)
+	if hand != nil {
+		grid.updateHandle(hand)
+	}
+}
+
+func (grid *Grid) ReindexQuery(f SpatialIndexQuery, data interface{}) {
+	// Update all of the handles first, numbering them so each pair is only reported once.
+	seq := 0
+	grid.handleSet.Each(func(hand *gridHandle) {
+		grid.updateHandle(hand)
+		hand.seq = seq
+		seq++
+	})
+
+	grid.handleSet.Each(func(hand *gridHandle) {
+		for j := hand.b; j <= hand.t; j++ {
+			for i := hand.l; i <= hand.r; i++ {
+				for _, other := range grid.cells[j*grid.cols+i] {
+					if other.seq >= hand.seq || other.stamp == grid.stamp {
+						continue
+					}
+
+					other.stamp = grid.stamp
+					if hand.bb.Intersects(other.bb) {
+						f(hand.obj, other.obj, 0, data)
+					}
+				}
+			}
+		}
+
+		grid.stamp++
+	})
+
+	// Reindex query is also responsible for colliding against the static index.
+	grid.CollideStatic(grid.staticIndex, f, data)
+}
+
+func (grid *Grid) Query(obj interface{}, bb BB, f SpatialIndexQuery, data interface{}) {
+	l, r := grid.cellX(bb.L), grid.cellX(bb.R)
+	b, t := grid.cellY(bb.B), grid.cellY(bb.T)
+
+	for j := b; j <= t; j++ {
+		for i := l; i <= r; i++ {
+			for _, hand := range grid.cells[j*grid.cols+i] {
+				if hand.stamp == grid.stamp || obj == hand.obj {
+					continue
+				}
+
+				hand.stamp = grid.stamp
+				if bb.Intersects(hand.bb) {
+					f(obj, hand.obj, 0, data)
+				}
+			}
+		}
+	}
+
+	grid.stamp++
+}
+
+func (grid *Grid) segmentQueryHelper(idx int, obj interface{}, f SpatialIndexSegmentQuery, data interface{}) float64 {
+	t := 1.0
+
+	for _, hand := range grid.cells[idx] {
+		if hand.stamp == grid.stamp {
+			continue
+		}
+
+		t = math.Min(t, f(obj, hand.obj, data))
+		hand.stamp = grid.stamp
+	}
+
+	return t
+}
+
+// SegmentQuery walks the cells along the segment in order, stopping once it passes t_exit.
+// Cells outside of the grid map to the nearest edge cell so objects outside of the bounds are still found.
+func (grid *Grid) SegmentQuery(obj interface{}, a, b Vector, t_exit float64, f SpatialIndexSegmentQuery, data interface{}) {
+	origin := Vector{grid.bounds.L, grid.bounds.B}
+	a = a.Sub(origin).Mult(1.0 / grid.cellSize)
+	b = b.Sub(origin).Mult(1.0 / grid.cellSize)
+
+	cellX := floor(a.X)
+	cellY := floor(a.Y)
+
+	t := 0.0
+
+	var xInc, yInc int
+	var tempV, tempH float64
+
+	if b.X > a.X {
+		xInc = 1
+		tempH = math.Floor(a.X+1.0) - a.X
+	} else {
+		xInc = -1
+		tempH = a.X - math.Floor(a.X)
+	}
+
+	if b.Y > a.Y {
+		yInc = 1
+		tempV = math.Floor(a.Y+1.0) - a.Y
+	} else {
+		yInc = -1
+		tempV = a.Y - math.Floor(a.Y)
+	}
+
+	dx := math.Abs(b.X - a.X)
+	dy := math.Abs(b.Y - a.Y)
+	var dtdx, dtdy float64
+	if dx != 0 {
+		dtdx = 1.0 / dx
+	} else {
+		dtdx = INFINITY
+	}
+
+	if dy != 0 {
+		dtdy = 1.0 / dy
+	} else {
+		dtdy = INFINITY
+	}
+
+	// A segment starting on a cell boundary going in the negative direction enters the neighbouring cell at t = 0.
+	nextH, nextV := INFINITY, INFINITY
+	if dx != 0 {
+		nextH = tempH * dtdx
+	}
+	if dy != 0 {
+		nextV = tempV * dtdy
+	}
+
+	for t < t_exit {
+		i := clampInt(cellX, 0, grid.cols-1)
+		j := clampInt(cellY, 0, grid.rows-1)
+		t_exit = math.Min(t_exit, grid.segmentQueryHelper(j*grid.cols+i, obj, f, data))
+
+		if nextV < nextH {
+			cellY += yInc
+			t = nextV
+			nextV += dtdy
+		} else {
+			cellX += xInc
+			t = nextH
+			nextH += dtdx
+		}
+	}
+
+	grid.stamp++
+}
+
+func clampInt(i, min, max int) int {
+	if i < min {
+		return min
+	} else if i > max {
+		return max
+	}
+	return i
+}
@@ -0,0 +1,119 @@
+package cp
+
+import "testing"
+
+// newTileSpace builds a tile world with a floor of tiles and a few loose boxes dropped on it.
+func newTileSpace(cols, boxes int) *Space {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+
+	const tile = 16.0
+	var shapes []*Shape
+	for i := 0; i < cols; i++ {
+		for j := 0; j < 4; j++ {
+			x, y := float64(i)*tile, -float64(j+1)*tile
+			shapes = append(shapes, NewBox2(space.StaticBody, BB{x, y, x + tile, y + tile}, 0))
+		}
+	}
+	space.AddShapes(shapes)
+
+	for i := 0; i < boxes; i++ {
+		body := space.AddBody(NewBody(1, MomentForBox(1, 10, 10)))
+		body.SetPosition(Vector{float64(i%cols)*tile + tile/2, 10 + float64(i/cols)*12})
+		space.AddShape(NewBox(body, 10, 10, 0))
+	}
+
+	return space
+}
+
+func TestGrid(t *testing.T) {
+	space := newTileSpace(40, 60)
+	space.UseGrid(16, BB{0, -64, 640, 256})
+
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	space.EachBody(func(body *Body) {
+		if body.GetType() == BODY_DYNAMIC && body.Position().Y < 0 {
+			t.Errorf("%v fell through the floor", body.Position())
+		}
+	})
+}
+
+func TestGrid_OutOfBounds(t *testing.T) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	space.UseGrid(16, BB{0, 0, 64, 64})
+
+	// The floor and the box are both well outside of the grid's bounds.
+	space.AddShape(NewSegment(space.StaticBody, Vector{-1000, -500}, Vector{-900, -500}, 0))
+	body := space.AddBody(NewBody(1, MomentForBox(1, 10, 10)))
+	body.SetPosition(Vector{-950, -480})
+	space.AddShape(NewBox(body, 10, 10, 0))
+
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	if body.Position().Y < -500 {
+		t.Errorf("%v fell through the floor", body.Position())
+	}
+}
+
+func TestGrid_SegmentQuery(t *testing.T) {
+	space := newTileSpace(40, 0)
+	space.UseGrid(16, BB{0, -64, 640, 256})
+
+	info := space.SegmentQueryFirst(Vector{100, 100}, Vector{100, -100}, 0, SHAPE_FILTER_ALL)
+	if info.Shape == nil || info.Point.Distance(Vector{100, 0}) > 1e-6 {
+		t.Errorf("expected to hit the floor at (100, 0), got %v", info.Point)
+	}
+
+	info = space.SegmentQueryFirst(Vector{-100, 100}, Vector{700, -100}, 0, SHAPE_FILTER_ALL)
+	if info.Shape == nil || info.Point.Y != 0 {
+		t.Errorf("expected a diagonal ray to hit the top of the floor, got %v", info.Point)
+	}
+
+	info = space.SegmentQueryFirst(Vector{100, 100}, Vector{200, 100}, 0, SHAPE_FILTER_ALL)
+	if info.Shape != nil {
+		t.Errorf("expected to miss, hit %v", info.Point)
+	}
+}
+
+func TestGrid_SegmentQueryFromCellBoundary(t *testing.T) {
+	space := NewSpace()
+	space.UseGrid(16, NewBB(0, 0, 64, 64))
+	space.AddShape(NewBox2(space.StaticBody, NewBB(2, 2, 10, 10), 0))
+
+	info := space.SegmentQueryFirst(Vector{32, 5}, Vector{0, 5}, 0, SHAPE_FILTER_ALL)
+	if info.Shape == nil || info.Point.Distance(Vector{10, 5}) > 1e-6 {
+		t.Errorf("expected to hit the box at (10, 5), got %v", info.Point)
+	}
+}
+
+func benchmarkTileSpace(b *testing.B, use func(space *Space)) {
+	space := newTileSpace(400, 800)
+	use(space)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		space.Step(1.0 / 60.0)
+	}
+}
+
+func BenchmarkTileSpace_BBTree(b *testing.B) {
+	benchmarkTileSpace(b, func(space *Space) {})
+}
+
+func BenchmarkTileSpace_SpaceHash(b *testing.B) {
+	benchmarkTileSpace(b, func(space *Space) {
+		space.UseSpatialHash(16, 10000)
+	})
+}
+
+func BenchmarkTileSpace_Grid(b *testing.B) {
+	benchmarkTileSpace(b, func(space *Space) {
+		space.UseGrid(16, BB{0, -64, 6400, 256})
+	})
+}
@@ -17,8 +17,12 @@ func SpaceArbiterSetFilter(arb *Arbiter, space *Space) bool {
 
 	if ticks >= 1 && arb.state != CP_ARBITER_STATE_CACHED {
 		arb.state = CP_ARBITER_STATE_CACHED
-		handler := arb.handler
-		handler.SeparateFunc(arb, space, handler.UserData)
+		if !arb.passThrough {
+			handler := arb.handler
+			handler.SeparateFunc(arb, space, handler.UserData)
+		}
+		space.recordEndEvent(arb, false)
+		arb.setSensorOverlap(false)
 	}
 
 	if ticks >= space.collisionPersistence {
@@ -36,13 +40,15 @@ func CachedArbitersFilter(arb *Arbiter, space *Space, shape *Shape, body *Body)
 	if (body == arb.body_a && (shape == arb.a || shape == nil)) ||
 		(body == arb.body_b && (shape == arb.b || shape == nil)) {
 		// Call separate when removing shapes.
-		if shape != nil && arb.state != CP_ARBITER_STATE_CACHED {
+		if shape != nil && arb.state != CP_ARBITER_STATE_CACHED && !arb.passThrough {
 			// Invalidate the arbiter since one of the shapes was removed
 			arb.state = CP_ARBITER_STATE_INVALIDATED
 
 			handler := arb.handler
 			handler.SeparateFunc(arb, space, handler.UserData)
 		}
+		space.recordEndEvent(arb, true)
+		arb.setSensorOverlap(false)
 
 		arb.Unthread()
 		for i, arbiter := range space.arbiters {
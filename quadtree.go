@@ -0,0 +1,345 @@
+package cp
+
+import "math"
+
+// QuadTree is a loose quadtree spatial index.
+//
+// Each node's loose bounds are twice the size of its cell, so every object is stored in exactly one node, chosen by its center and size.
+// This keeps the tree cheap to update while adapting to uneven object density, such as open world maps.
+// Queries are pruned using the bounds of the objects actually in each subtree, which are refit once per step.
+// Objects outside of the tree's bounds are kept in the root node. They still collide correctly, but more slowly.
+type QuadTree struct {
+	*SpatialIndex
+
+	root     *quadNode
+	maxDepth int
+
+	handleSet *HashSet[*Shape, *quadHandle]
+}
+
+type quadNode struct {
+	parent *quadNode
+
+	// center and half width of the cell, the loose bounds extend another half width past the cell
+	center Vector
+	half   float64
+
+	// bounds of the objects in this node and its children, may be larger than needed until the next refit
+	bb BB
+
+	// children are allocated together when the node is first split
+	children *[4]quadNode
+	handles  []*quadHandle
+
+	// number of objects in this node and its children
+	count int
+}
+
+type quadHandle struct {
+	obj   *Shape
+	bb    BB
+	node  *quadNode
+	index int
+	seq   int
+}
+
+// NewQuadTree creates a loose quadtree covering bounds, which is made square if needed.
+// Nodes are split at most maxDepth times.
+func NewQuadTree(bounds BB, maxDepth int, bbfunc SpatialIndexBB, staticIndex *SpatialIndex) *SpatialIndex {
+	assert(maxDepth >= 0, "QuadTree depth must not be negative.")
+
+	half := math.Max(bounds.R-bounds.L, bounds.T-bounds.B) * 0.5
+	quadTree := &QuadTree{
+		root:     newQuadNode(nil, bounds.Center(), half),
+		maxDepth: maxDepth,
+		handleSet: NewHashSet[*Shape, *quadHandle](func(obj *Shape, elt *quadHandle) bool {
+			return obj == elt.obj
+		}),
+	}
+	spatialIndex := NewSpatialIndex(quadTree, bbfunc, staticIndex)
+	quadTree.SpatialIndex = spatialIndex
+	return spatialIndex
+}
+
+func newQuadNode(parent *quadNode, center Vector, half float64) *quadNode {
+	node := &quadNode{}
+	node.init(parent, center, half)
+	return node
+}
+
+func (node *quadNode) init(parent *quadNode, center Vector, half float64) {
+	*node = quadNode{
+		parent: parent,
+		center: center,
+		half:   half,
+	}
+}
+
+func (node *quadNode) child(quadrant int) *quadNode {
+	if node.children == nil {
+		node.children = &[4]quadNode{}
+		half := node.half * 0.5
+		for i := range node.children {
+			center := node.center
+			if i&1 == 0 {
+				center.X -= half
+			} else {
+				center.X += half
+			}
+			if i&2 == 0 {
+				center.Y -= half
+			} else {
+				center.Y += half
+			}
+			node.children[i].init(node, center, half)
+		}
+	}
+	return &node.children[quadrant]
+}
+
+func (node *quadNode) quadrantFor(v Vector) int {
+	quadrant := 0
+	if v.X > node.center.X {
+		quadrant |= 1
+	}
+	if v.Y > node.center.Y {
+		quadrant |= 2
+	}
+	return quadrant
+}
+
+// find returns the deepest node whose loose bounds fit bb, creating nodes as needed.
+func (quadTree *QuadTree) find(bb BB) *quadNode {
+	node := quadTree.root
+	center := bb.Center()
+	extent := math.Max(bb.R-bb.L, bb.T-bb.B) * 0.5
+
+	// Objects centered outside of the tree stay in the root.
+	if math.Abs(center.X-node.center.X) > node.half || math.Abs(center.Y-node.center.Y) > node.half {
+		return node
+	}
+
+	for depth := 0; depth < quadTree.maxDepth && extent <= node.half*0.5; depth++ {
+		node = node.child(node.quadrantFor(center))
+	}
+	return node
+}
+
+func (quadTree *QuadTree) addHandle(hand *quadHandle, node *quadNode) {
+	hand.node = node
+	hand.index = len(node.handles)
+	node.handles = append(node.handles, hand)
+
+	for ; node != nil; node = node.parent {
+		if node.count == 0 {
+			node.bb = hand.bb
+		} else {
+			node.bb = node.bb.Merge(hand.bb)
+		}
+		node.count++
+	}
+}
+
+func (quadTree *QuadTree) removeHandle(hand *quadHandle) {
+	node := hand.node
+
+	// leak-free delete from slice
+	last := len(node.handles) - 1
+	moved := node.handles[last]
+	node.handles[hand.index] = moved
+	moved.index = hand.index
+	node.handles[last] = nil
+	node.handles = node.handles[:last]
+
+	// Update the counts and free the children of nodes that became empty.
+	for ; node != nil; node = node.parent {
+		node.count--
+		if node.count == 0 {
+			node.children = nil
+		}
+	}
+	hand.node = nil
+}
+
+// updateHandle moves the handle to the node that fits its new bounding box.
+// The bounds of its ancestors must be refit or expanded afterwards.
+func (quadTree *QuadTree) updateHandle(hand *quadHandle) {
+	hand.bb = quadTree.bbfunc(hand.obj)
+
+	node := quadTree.find(hand.bb)
+	if node != hand.node {
+		quadTree.removeHandle(hand)
+		// Removing may have freed the node's ancestors, so look it up again.
+		quadTree.addHandle(hand, quadTree.find(hand.bb))
+	}
+}
+
+// refit shrinks the bounds of the subtree to fit the objects in it.
+func (node *quadNode) refit() BB {
+	bb := BB{INFINITY, INFINITY, -INFINITY, -INFINITY}
+	for _, hand := range node.handles {
+		bb = bb.Merge(hand.bb)
+	}
+
+	if node.children != nil {
+		for i := range node.children {
+			if child := &node.children[i]; child.count > 0 {
+				bb = bb.Merge(child.refit())
+			}
+		}
+	}
+
+	node.bb = bb
+	return bb
+}
+
+func (quadTree *QuadTree) Count() int {
+	return int(quadTree.handleSet.Count())
+}
+
+func (quadTree *QuadTree) Each(f SpatialIndexIterator) {
+	quadTree.handleSet.Each(func(hand *quadHandle) {
+		f(hand.obj)
+	})
+}
+
+func (quadTree *QuadTree) Contains(obj *Shape, hashId HashValue) bool {
+	return quadTree.handleSet.Find(hashId, obj) != nil
+}
+
+func (quadTree *QuadTree) Insert(obj *Shape, hashId HashValue) {
+	quadTree.handleSet.Insert(hashId, obj, func(obj *Shape) *quadHandle {
+		hand := &quadHandle{obj: obj, bb: quadTree.bbfunc(obj)}
+		quadTree.addHandle(hand, quadTree.find(hand.bb))
+		return hand
+	})
+}
+
+func (quadTree *QuadTree) Remove(obj *Shape, hashId HashValue) {
+	hand := quadTree.handleSet.Remove(hashId, obj)
+	if hand != nil {
+		quadTree.removeHandle(hand)
+	}
+}
+
+func (quadTree *QuadTree) Reindex() {
+	quadTree.handleSet.Each(func(hand *quadHandle) {
+		quadTree.updateHandle(hand)
+	})
+	if quadTree.root.count > 0 {
+		quadTree.root.refit()
+	}
+}
+
+func (quadTree *QuadTree) ReindexObject(obj *Shape, hashId HashValue) {
+	hand := quadTree.handleSet.Find(hashId, obj)
+	if hand != nil {
+		quadTree.updateHandle(hand)
+		for node := hand.node; node != nil; node = node.parent {
+			node.bb = node.bb.Merge(hand.bb)
+		}
+	}
+}
+
+func (quadTree *QuadTree) ReindexQuery(f SpatialIndexQuery, data interface{}) {
+	// Update all of the handles first, numbering them so each pair is only reported once.
+	seq := 0
+	quadTree.handleSet.Each(func(hand *quadHandle) {
+		quadTree.updateHandle(hand)
+		hand.seq = seq
+		seq++
+	})
+	if quadTree.root.count > 0 {
+		quadTree.root.refit()
+	}
+
+	quadTree.handleSet.Each(func(hand *quadHandle) {
+		quadTree.root.queryPairs(hand, f, data)
+	})
+
+	// Reindex query is also responsible for colliding against the static index.
+	quadTree.CollideStatic(quadTree.staticIndex, f, data)
+}
+
+// query calls f for each handle in the subtree whose bounding box intersects bb.
+// The caller checks the node's bounds.
+func (node *quadNode) query(bb BB, f func(hand *quadHandle)) {
+	for _, hand := range node.handles {
+		if hand.bb.Intersects(bb) {
+			f(hand)
+		}
+	}
+
+	if node.children != nil {
+		for i := range node.children {
+			child := &node.children[i]
+			if child.count > 0 && child.bb.Intersects(bb) {
+				child.query(bb, f)
+			}
+		}
+	}
+}
+
+// queryPairs reports the handles in the subtree that overlap hand and were numbered before it.
+func (node *quadNode) queryPairs(hand *quadHandle, f SpatialIndexQuery, data interface{}) {
+	bb := hand.bb
+	for _, other := range node.handles {
+		if other.seq < hand.seq && other.bb.Intersects(bb) {
+			f(hand.obj, other.obj, 0, data)
+		}
+	}
+
+	if node.children != nil {
+		for i := range node.children {
+			child := &node.children[i]
+			if child.count > 0 && child.bb.Intersects(bb) {
+				child.queryPairs(hand, f, data)
+			}
+		}
+	}
+}
+
+func (quadTree *QuadTree) Query(obj interface{}, bb BB, f SpatialIndexQuery, data interface{}) {
+	quadTree.root.query(bb, func(hand *quadHandle) {
+		if obj != hand.obj {
+			f(obj, hand.obj, 0, data)
+		}
+	})
+}
+
+func (node *quadNode) segmentQuery(obj interface{}, a, b Vector, t_exit float64, f SpatialIndexSegmentQuery, data interface{}) float64 {
+	for _, hand := range node.handles {
+		if hand.bb.SegmentQuery(a, b) < t_exit {
+			t_exit = math.Min(t_exit, f(obj, hand.obj, data))
+		}
+	}
+
+	// Visit the children nearest first so they can shorten the query for the rest.
+	var order [4]*quadNode
+	var entry [4]float64
+	n := 0
+	for i := 0; node.children != nil && i < 4; i++ {
+		child := &node.children[i]
+		if child.count == 0 {
+			continue
+		}
+
+		t := child.bb.SegmentQuery(a, b)
+		i := n
+		for ; i > 0 && entry[i-1] > t; i-- {
+			order[i], entry[i] = order[i-1], entry[i-1]
+		}
+		order[i], entry[i] = child, t
+		n++
+	}
+
+	for i := 0; i < n && entry[i] < t_exit; i++ {
+		t_exit = math.Min(t_exit, order[i].segmentQuery(obj, a, b, t_exit, f, data))
+	}
+
+	return t_exit
+}
+
+func (quadTree *QuadTree) SegmentQuery(obj interface{}, a, b Vector, t_exit float64, f SpatialIndexSegmentQuery, data interface{}) {
+	quadTree.root.segmentQuery(obj, a, b, t_exit, f, data)
+}
@@ -0,0 +1,35 @@
+package cp
+
+import "testing"
+
+func TestQuadTree(t *testing.T) {
+	space := NewSpace()
+	space.UseQuadTree(NewBB(-100, -100, 100, 100), 5)
+
+	var shapes []*Shape
+	for i := 0; i < 20; i++ {
+		body := space.AddBody(NewBody(1, MomentForCircle(1, 0, 1, Vector{})))
+		body.SetPosition(Vector{float64(i)*9 - 90, 50})
+		shapes = append(shapes, space.AddShape(NewCircle(body, 1, Vector{})))
+	}
+	// A shape well outside of the tree's bounds.
+	shapes = append(shapes, space.AddShape(NewCircle(space.StaticBody, 5, Vector{500, 500})))
+
+	quadTree := space.DynamicIndex().Class().(*QuadTree)
+	if quadTree.root.count != 20 {
+		t.Errorf("expected 20 shapes in the tree, got %v", quadTree.root.count)
+	}
+	if hit := space.PointQueryNearest(Vector{500, 500}, 0, SHAPE_FILTER_ALL); hit.Shape != shapes[20] {
+		t.Error("expected to find the shape outside of the bounds")
+	}
+
+	for _, shape := range shapes {
+		space.RemoveShape(shape)
+	}
+	if quadTree.root.count != 0 {
+		t.Errorf("expected the tree to be empty, got %v shapes", quadTree.root.count)
+	}
+	if quadTree.root.children != nil {
+		t.Error("expected empty nodes to be freed")
+	}
+}
@@ -0,0 +1,94 @@
+package cp
+
+import "math"
+
+// FalloffFunc scales the strength of a radial impulse given the distance from its center and its radius.
+type FalloffFunc func(distance, radius float64) float64
+
+// FalloffNone applies the full impulse anywhere within the radius.
+func FalloffNone(_, _ float64) float64 {
+	return 1
+}
+
+// FalloffLinear fades the impulse linearly to zero at the radius.
+func FalloffLinear(distance, radius float64) float64 {
+	return Clamp01(1 - distance/radius)
+}
+
+// FalloffQuadratic fades the impulse quadratically to zero at the radius.
+func FalloffQuadratic(distance, radius float64) float64 {
+	f := Clamp01(1 - distance/radius)
+	return f * f
+}
+
+// ApplyRadialImpulse applies an outward impulse to every dynamic body within radius of center.
+//
+// Each body is pushed once at the point of its nearest shape, scaled by falloff (no falloff if nil).
+// Bodies hidden from the center by another shape are not affected, so walls block the blast.
+// Sensors are ignored and sleeping bodies are woken up.
+func (space *Space) ApplyRadialImpulse(center Vector, radius, impulse float64, falloff FalloffFunc, filter ShapeFilter) {
+	if falloff == nil {
+		falloff = FalloffNone
+	}
+
+	// Find the nearest shape of every body in range.
+	var hits []PointQueryInfo
+	index := map[*Body]int{}
+	space.PointQuery(center, radius, filter, func(shape *Shape, point Vector, distance float64, gradient Vector, _ interface{}) {
+		body := shape.body
+		if shape.sensor || body.GetType() != BODY_DYNAMIC {
+			return
+		}
+
+		info := PointQueryInfo{shape, point, distance, gradient}
+		if i, ok := index[body]; !ok {
+			index[body] = len(hits)
+			hits = append(hits, info)
+		} else if distance < hits[i].Distance {
+			hits[i] = info
+		}
+	}, nil)
+
+	for _, info := range hits {
+		if !space.lineOfSight(center, info, filter) {
+			continue
+		}
+
+		// The gradient points from the shape towards the center.
+		distance := math.Max(info.Distance, 0)
+		j := info.Gradient.Neg().Mult(impulse * falloff(distance, radius))
+		info.Shape.body.ApplyImpulseAtWorldPoint(j, info.Point)
+	}
+}
+
+// ApplyRadialImpulseRays applies a radial impulse by casting evenly spaced rays out from center.
+//
+// The impulse is split evenly between the rays, and each ray pushes the first dynamic body it hits.
+// This is more expensive than ApplyRadialImpulse, but bodies that are partially hidden receive a matching share of the blast.
+func (space *Space) ApplyRadialImpulseRays(center Vector, radius, impulse float64, rays int, falloff FalloffFunc, filter ShapeFilter) {
+	assert(rays > 0, "Must cast at least one ray")
+	if falloff == nil {
+		falloff = FalloffNone
+	}
+
+	share := impulse / float64(rays)
+	for i := 0; i < rays; i++ {
+		dir := ForAngle(2 * math.Pi * float64(i) / float64(rays))
+		info := space.SegmentQueryFirst(center, center.Add(dir.Mult(radius)), 0, filter)
+		if info.Shape == nil || info.Shape.body.GetType() != BODY_DYNAMIC {
+			continue
+		}
+
+		j := dir.Mult(share * falloff(info.Alpha*radius, radius))
+		info.Shape.body.ApplyImpulseAtWorldPoint(j, info.Point)
+	}
+}
+
+func (space *Space) lineOfSight(center Vector, info PointQueryInfo, filter ShapeFilter) bool {
+	if info.Distance <= 0 {
+		return true
+	}
+
+	hit := space.SegmentQueryFirst(center, info.Point, 0, filter)
+	return hit.Shape == nil || hit.Shape.body == info.Shape.body
+}
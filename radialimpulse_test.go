@@ -0,0 +1,35 @@
+package cp
+
+import "testing"
+
+func TestSpace_ApplyRadialImpulse(t *testing.T) {
+	space := NewSpace()
+
+	right := space.AddBody(NewBody(1, MomentForCircle(1, 0, 1, Vector{})))
+	right.SetPosition(Vector{5, 0})
+	space.AddShape(NewCircle(right, 1, Vector{}))
+
+	// A wall between the center and the left body.
+	space.AddShape(NewSegment(space.StaticBody, Vector{-2, -5}, Vector{-2, 5}, 0))
+	left := space.AddBody(NewBody(1, MomentForCircle(1, 0, 1, Vector{})))
+	left.SetPosition(Vector{-5, 0})
+	space.AddShape(NewCircle(left, 1, Vector{}))
+
+	space.ApplyRadialImpulse(Vector{}, 10, 10, FalloffLinear, SHAPE_FILTER_ALL)
+
+	if v := right.Velocity(); v.X <= 0 || v.Y != 0 {
+		t.Errorf("expected right body to be pushed right, got %v", v)
+	}
+	if v := left.Velocity(); !v.Equal(Vector{}) {
+		t.Errorf("expected wall to block the blast, got %v", v)
+	}
+
+	right.SetVelocity(0, 0)
+	space.ApplyRadialImpulseRays(Vector{}, 10, 10, 64, nil, SHAPE_FILTER_ALL)
+	if v := right.Velocity(); v.X <= 0 {
+		t.Errorf("expected right body to be pushed right, got %v", v)
+	}
+	if v := left.Velocity(); !v.Equal(Vector{}) {
+		t.Errorf("expected wall to block the blast, got %v", v)
+	}
+}
@@ -33,12 +33,20 @@ type Shape struct {
 	e, u     float64
 	surfaceV Vector
 
+	// skip computing contact points when the shape is a sensor
+	sensorOverlapOnly bool
+	// the shapes overlapping the shape if it is a sensor, and the sensors overlapping it
+	sensorOverlaps []*Shape
+
 	UserData interface{}
 
 	collisionType CollisionType
 	Filter        ShapeFilter
 
 	hashid HashValue
+
+	fluid  *FluidVolume
+	oneWay Vector
 }
 
 func (s Shape) String() string {
@@ -67,6 +75,17 @@ func (s *Shape) SetSensor(sensor bool) {
 	s.sensor = sensor
 }
 
+func (s *Shape) SensorOverlapOnly() bool {
+	return s.sensorOverlapOnly
+}
+
+// SetSensorOverlapOnly skips computing the contact points of a sensor when only the overlap matters.
+// The contact point set of its arbiters then holds the closest points of the shapes as a single contact.
+// It has no effect unless the shape is a sensor.
+func (s *Shape) SetSensorOverlapOnly(overlapOnly bool) {
+	s.sensorOverlapOnly = overlapOnly
+}
+
 func (s *Shape) Space() *Space {
 	return s.space
 }
@@ -163,6 +182,27 @@ func (s *Shape) SetElasticity(e float64) {
 	s.e = e
 }
 
+// OneWay returns the direction set with SetOneWay, or a zero vector if the shape collides from all sides.
+func (s *Shape) OneWay() Vector {
+	return s.oneWay
+}
+
+// SetOneWay makes the shape only collide with objects on the side it's facing, like a one-way platform.
+//
+// When a collision starts, it is ignored if the collision normal pointing away from the shape opposes direction.
+// An ignored collision stays ignored until the shapes separate, so objects passing through can't get stuck halfway.
+// No collision handler functions are called for it, not even begin or separate.
+// Pass a zero vector to collide from all sides again.
+func (s *Shape) SetOneWay(direction Vector) {
+	s.body.Activate()
+	s.oneWay = direction.Normalize()
+}
+
+// rejectsOneWay returns true if a collision with the normal n pointing away from the shape should pass through it.
+func (s *Shape) rejectsOneWay(n Vector) bool {
+	return s.oneWay.Dot(n) < 0
+}
+
 func (s *Shape) SetFilter(filter ShapeFilter) {
 	s.body.Activate()
 	s.Filter = filter
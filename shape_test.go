@@ -37,3 +37,65 @@ func TestShapeCircleDensity(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestShapeOneWay(t *testing.T) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	platform := space.AddShape(NewSegment(space.StaticBody, Vector{-10, 0}, Vector{10, 0}, 1))
+	platform.SetOneWay(Vector{0, 1})
+
+	// Jumping up through the platform from below.
+	below := space.AddBody(NewBody(1, MomentForCircle(1, 0, 1, Vector{})))
+	below.SetPosition(Vector{-5, -3})
+	below.SetVelocity(0, 60)
+	space.AddShape(NewCircle(below, 1, Vector{}))
+
+	// Falling onto the platform from above.
+	above := space.AddBody(NewBody(1, MomentForCircle(1, 0, 1, Vector{})))
+	above.SetPosition(Vector{5, 3})
+	space.AddShape(NewCircle(above, 1, Vector{}))
+
+	for i := 0; i < 30; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	if y := below.Position().Y; y < 2 {
+		t.Errorf("expected body to pass up through the platform, got y=%v", y)
+	}
+	if y := above.Position().Y; y < 1.5 {
+		t.Errorf("expected body to land on the platform, got y=%v", y)
+	}
+}
+
+func TestShapeOneWay_Callbacks(t *testing.T) {
+	space, ball := newHandlerSpace()
+	var ground *Shape
+	space.StaticBody.EachShape(func(shape *Shape) { ground = shape })
+	ground.SetOneWay(Vector{0, 1})
+
+	var counts handlerCounts
+	countCalls(space.NewCollisionHandler(testGroundType, testBallType), &counts)
+
+	// Jumping up through the ground from below, and landing on it from above.
+	ball.SetPosition(Vector{0, -10})
+	ball.SetVelocity(0, 70)
+	var passing handlerCounts
+	peaked := false
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+		if !peaked && ball.Velocity().Y < 0 {
+			peaked = true
+			passing = counts
+			if y := ball.Position().Y; y < 6 {
+				t.Fatalf("expected the ball to pass up through the ground, got y=%v", y)
+			}
+		}
+	}
+
+	if passing != (handlerCounts{}) {
+		t.Errorf("expected no callbacks while passing through, got %+v", passing)
+	}
+	if counts.begin != 1 || counts.separate != 0 {
+		t.Errorf("expected the ball to land on the ground, got %+v", counts)
+	}
+}
@@ -0,0 +1,216 @@
+package cp
+
+import "testing"
+
+func newSleepySpace() *Space {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	space.SleepTimeThreshold = 0.5
+	ground := space.AddShape(NewSegment(space.StaticBody, Vector{-100, 0}, Vector{100, 0}, 0))
+	ground.SetFriction(1)
+	return space
+}
+
+func addSleepyBox(space *Space, p Vector) *Body {
+	body := space.AddBody(NewBody(1, MomentForBox(1, 10, 10)))
+	body.SetPosition(p)
+	space.AddShape(NewBox(body, 10, 10, 0)).SetFriction(1)
+	return body
+}
+
+func TestSpace_SleepCallbacks(t *testing.T) {
+	space := newSleepySpace()
+	bottom := addSleepyBox(space, Vector{0, 5})
+	top := addSleepyBox(space, Vector{0, 15})
+	alone := addSleepyBox(space, Vector{50, 5})
+
+	slept := map[*Body]int{}
+	woken := map[*Body]int{}
+	space.OnBodySleep = func(body *Body) {
+		if !body.IsSleeping() {
+			t.Error("expected the body to be asleep in OnBodySleep")
+		}
+		slept[body]++
+	}
+	space.OnBodyWake = func(body *Body) {
+		if body.IsSleeping() {
+			t.Error("expected the body to be awake in OnBodyWake")
+		}
+		woken[body]++
+	}
+
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	for _, body := range []*Body{bottom, top, alone} {
+		if slept[body] != 1 {
+			t.Errorf("expected %v to fall asleep once, got %v", body, slept[body])
+		}
+	}
+
+	components := space.SleepingComponents()
+	if len(components) != 2 {
+		t.Fatalf("expected 2 sleeping components, got %v", len(components))
+	}
+	for _, component := range components {
+		if len(component) == 2 && !(component[0] == bottom || component[1] == bottom) {
+			t.Error("expected the stacked boxes in the same component")
+		}
+		for _, body := range component {
+			if body.IdleTime() < space.SleepTimeThreshold {
+				t.Errorf("expected the idle time of %v to reach the threshold, got %v", body, body.IdleTime())
+			}
+		}
+	}
+
+	// Waking one body of the stack wakes the whole stack, but not the other box.
+	top.ApplyImpulseAtWorldPoint(Vector{0, 10}, top.Position())
+	if woken[top] != 1 || woken[bottom] != 1 || woken[alone] != 0 {
+		t.Errorf("expected the stack to wake once, got %v", woken)
+	}
+	top.Activate()
+	if woken[top] != 1 {
+		t.Errorf("expected no wake events for awake bodies, got %v", woken[top])
+	}
+	if components := space.SleepingComponents(); len(components) != 1 || components[0][0] != alone {
+		t.Errorf("expected only the single box to be asleep, got %v", components)
+	}
+}
+
+func TestBody_AllowSleep(t *testing.T) {
+	space := newSleepySpace()
+	player := addSleepyBox(space, Vector{0, 5})
+	player.SetAllowSleep(false)
+	crate := addSleepyBox(space, Vector{0, 15})
+	debris := addSleepyBox(space, Vector{50, 5})
+
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	if player.IsSleeping() || crate.IsSleeping() {
+		t.Error("expected the player and the crate on it to stay awake")
+	}
+	if !debris.IsSleeping() {
+		t.Error("expected the debris to fall asleep")
+	}
+
+	player.SetAllowSleep(true)
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if !player.IsSleeping() || !crate.IsSleeping() {
+		t.Error("expected the player and the crate to fall asleep once allowed")
+	}
+
+	player.SetAllowSleep(false)
+	if player.IsSleeping() || crate.IsSleeping() {
+		t.Error("expected disallowing sleep to wake the player's component")
+	}
+}
+
+func TestBody_SleepThresholds(t *testing.T) {
+	space := newSleepySpace()
+	space.SleepTimeThreshold = 10
+	quick := addSleepyBox(space, Vector{0, 5})
+	quick.SetSleepTimeThreshold(0.5)
+	normal := addSleepyBox(space, Vector{50, 5})
+
+	// A box sliding slowly over frictionless ground is only idle with a higher idle speed threshold.
+	ice := space.AddShape(NewSegment(space.StaticBody, Vector{-100, -100}, Vector{100, -100}, 0))
+	ice.SetFriction(0)
+	sliding := addSleepyBox(space, Vector{0, -95})
+	sliding.SetSleepTimeThreshold(0.5)
+	sliding.SetIdleSpeedThreshold(5)
+	sliding.SetVelocity(1, 0)
+
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	if !quick.IsSleeping() {
+		t.Error("expected the body with a short sleep time threshold to fall asleep")
+	}
+	if normal.IsSleeping() {
+		t.Error("expected the body using the space's threshold to stay awake")
+	}
+	if !sliding.IsSleeping() {
+		t.Error("expected the slowly sliding body to fall asleep")
+	}
+}
+
+func TestBody_SleepWithGroup(t *testing.T) {
+	space := newSleepySpace()
+	a := addSleepyBox(space, Vector{-20, 50})
+	b := addSleepyBox(space, Vector{20, 50})
+	c := addSleepyBox(space, Vector{60, 50})
+
+	var slept []*Body
+	space.OnBodySleep = func(body *Body) {
+		slept = append(slept, body)
+	}
+
+	a.SleepWithGroup(nil)
+	b.SleepWithGroup(a)
+	c.SleepWithGroup(nil)
+	if len(slept) != 3 {
+		t.Errorf("expected 3 sleep events, got %v", len(slept))
+	}
+
+	// Sleeping bodies stay put in the air.
+	for i := 0; i < 30; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	if !a.IsSleeping() || !b.IsSleeping() || a.Position().Y != 50 {
+		t.Fatal("expected the grouped bodies to stay asleep")
+	}
+	if components := space.SleepingComponents(); len(components) != 2 {
+		t.Errorf("expected 2 sleeping components, got %v", len(components))
+	}
+
+	b.Activate()
+	if a.IsSleeping() || b.IsSleeping() {
+		t.Error("expected the group to wake together")
+	}
+	if !c.IsSleeping() {
+		t.Error("expected the other component to stay asleep")
+	}
+}
+
+func TestBody_Sleep(t *testing.T) {
+	space := newSleepySpace()
+
+	// A level starts with a row of debris asleep on the ground.
+	var debris []*Body
+	for i := 0; i < 10; i++ {
+		body := addSleepyBox(space, Vector{-90 + float64(i)*20, 5})
+		body.Sleep()
+		debris = append(debris, body)
+	}
+	if components := space.SleepingComponents(); len(components) != len(debris) {
+		t.Fatalf("expected %v sleeping components, got %v", len(debris), len(components))
+	}
+
+	ball := space.AddBody(NewBody(1, MomentForCircle(1, 0, 5, Vector{})))
+	ball.SetPosition(Vector{-90, 30})
+	space.AddShape(NewCircle(ball, 5, Vector{}))
+
+	woken := map[*Body]bool{}
+	space.OnBodyWake = func(body *Body) {
+		woken[body] = true
+	}
+
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	if !woken[debris[0]] {
+		t.Error("expected the debris the ball landed on to wake up")
+	}
+	for _, body := range debris[1:] {
+		if woken[body] || !body.IsSleeping() {
+			t.Errorf("expected untouched debris at %v to stay asleep", body.Position())
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cp
+
+import (
+	"math"
+	"testing"
+)
+
+func newStackSpace(height int) (*Space, []*Body) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	space.AddShape(NewSegment(space.StaticBody, Vector{-100, 0}, Vector{100, 0}, 0)).SetFriction(1)
+
+	var bodies []*Body
+	for i := 0; i < height; i++ {
+		body := space.AddBody(NewBody(1, MomentForBox(1, 10, 10)))
+		body.SetPosition(Vector{0, 5 + float64(i)*10})
+		space.AddShape(NewBox(body, 10, 10, 0)).SetFriction(0.8)
+		bodies = append(bodies, body)
+	}
+	return space, bodies
+}
+
+func TestSolverSubstep_Stack(t *testing.T) {
+	// A tall stack with as many passes over the contacts as 4 iterations of the default solver.
+	stackTop := func(solver Solver) Vector {
+		space, bodies := newStackSpace(12)
+		space.Iterations = 4
+		space.SetSolver(solver, 4)
+
+		for i := 0; i < 600; i++ {
+			space.Step(1.0 / 60.0)
+		}
+		return bodies[len(bodies)-1].Position()
+	}
+
+	// The default solver doesn't converge with the same budget, and the stack topples.
+	if top := stackTop(SolverSequentialImpulse); top.Near(Vector{0, 115}, 1) {
+		t.Errorf("expected the stack to fall with the sequential impulse solver, top of the stack is at %v", top)
+	}
+	if top := stackTop(SolverSubstep); !top.Near(Vector{0, 115}, 1) {
+		t.Errorf("expected the stack to stay standing, top of the stack is at %v", top)
+	}
+}
+
+func TestSolverSubstep_Chain(t *testing.T) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+	space.SetSolver(SolverSubstep, 8)
+
+	// A chain with a heavy weight on the end.
+	prev := space.StaticBody
+	var links []*Body
+	for i := 0; i < 20; i++ {
+		mass := 1.0
+		if i == 19 {
+			mass = 50
+		}
+		body := space.AddBody(NewBody(mass, MomentForBox(mass, 10, 2)))
+		body.SetPosition(Vector{float64(i)*10 + 5, 0})
+		space.AddConstraint(NewPivotJoint(prev, body, Vector{float64(i) * 10, 0}))
+		links = append(links, body)
+		prev = body
+	}
+
+	for i := 0; i < 300; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	for i := 1; i < len(links); i++ {
+		if stretch := links[i].Position().Distance(links[i-1].Position()) - 10; stretch > 0.05 {
+			t.Errorf("link %v stretched by %v", i, stretch)
+		}
+	}
+}
+
+func TestSolverSubstep_Forces(t *testing.T) {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -10})
+	space.SetSolver(SolverSubstep, 4)
+
+	body := space.AddBody(NewBody(2, 1))
+	body.SetForce(Vector{4, 0})
+	space.Step(0.5)
+
+	// The force is applied over the whole step, not just the first substep.
+	if v := body.Velocity(); !v.Near(Vector{1, -5}, 1e-9) {
+		t.Errorf("expected a velocity of (1, -5), got %v", v)
+	}
+	if body.Force() != (Vector{}) {
+		t.Errorf("expected the force to be cleared, got %v", body.Force())
+	}
+	// Integrating in substeps is semi-implicit Euler with a smaller step.
+	if p := body.Position(); math.Abs(p.Y-(-10*0.125*0.125*(1+2+3+4))) > 1e-9 {
+		t.Errorf("unexpected position %v", p)
+	}
+}
@@ -9,15 +9,42 @@ import (
 const MAX_CONTACTS_PER_ARBITER = 2
 const CONTACTS_BUFFER_SIZE = 1024
 
+// Solver selects how a space resolves contacts and joints. See Space.SetSolver.
+type Solver int
+
+const (
+	// SolverSequentialImpulse integrates the step once and runs Space.Iterations passes of sequential impulses. This is the default.
+	SolverSequentialImpulse Solver = iota
+	// SolverSubstep splits each step into substeps, and integrates and relaxes the contacts and joints once per substep.
+	SolverSubstep
+)
+
 type Space struct {
 	Iterations uint // must be non-zero
 
-	gravity Vector
-	damping float64
+	solver   Solver
+	substeps uint
+
+	speculative bool
+
+	gravity       Vector
+	gravityFields []GravityField
+	damping       float64
+
+	maxLinearSpeed  float64
+	maxAngularSpeed float64
+	speedClampFunc  BodySpeedClampFunc
 
 	IdleSpeedThreshold float64
 	SleepTimeThreshold float64
 
+	// OnBodySleep is called for each body of a component when the component falls asleep,
+	// and for bodies put to sleep with Body.SleepWithGroup.
+	OnBodySleep func(body *Body)
+	// OnBodyWake is called for each body of a sleeping component when the component wakes up.
+	// It may be called while the space is locked, use AddPostStepCallback to add or remove objects.
+	OnBodyWake func(body *Body)
+
 	collisionSlop        float64
 	collisionBias        float64
 	collisionPersistence uint
@@ -47,6 +74,11 @@ type Space struct {
 	collisionHandlers *HashSet[*CollisionHandler, *CollisionHandler]
 	defaultHandler    *CollisionHandler
 
+	// handlers added for fluid volumes, by collision type
+	fluidHandlers map[CollisionType]*PriorityHandler
+
+	events contactEvents
+
 	skipPostStep      bool
 	postStepCallbacks []*PostStepCallback
 
@@ -82,6 +114,7 @@ func NewSpace() *Space {
 		cachedArbiters:       NewHashSet[ShapePair, *Arbiter](arbiterSetEql),
 		pooledArbiters:       sync.Pool{New: func() interface{} { return &Arbiter{} }},
 		constraints:          []*Constraint{},
+		events:               contactEvents{hitThreshold: 1},
 		collisionHandlers: NewHashSet[*CollisionHandler, *CollisionHandler](func(a, b *CollisionHandler) bool {
 			if a.TypeA == b.TypeA && a.TypeB == b.TypeB {
 				return true
@@ -115,10 +148,41 @@ func (space *Space) Gravity() Vector {
 
 func (space *Space) SetGravity(gravity Vector) {
 	space.gravity = gravity
+	space.activateSleepingComponents()
+}
+
+// AddGravityField adds a field whose gravity is added to the space's gravity for each body.
+func (space *Space) AddGravityField(field GravityField) GravityField {
+	space.gravityFields = append(space.gravityFields, field)
+	space.activateSleepingComponents()
+	return field
+}
+
+// RemoveGravityField removes a field added with AddGravityField.
+func (space *Space) RemoveGravityField(field GravityField) {
+	for i, f := range space.gravityFields {
+		if f == field {
+			// leak-free delete from slice
+			last := len(space.gravityFields) - 1
+			copy(space.gravityFields[i:], space.gravityFields[i+1:])
+			space.gravityFields[last] = nil
+			space.gravityFields = space.gravityFields[:last]
+			break
+		}
+	}
+	space.activateSleepingComponents()
+}
 
+// GravityFields returns the fields added with AddGravityField.
+func (space *Space) GravityFields() []GravityField {
+	return space.gravityFields
+}
+
+func (space *Space) activateSleepingComponents() {
 	// Wake up all of the bodies since the gravity changed.
-	for _, component := range space.sleepingComponents {
-		component.Activate()
+	// Activating a component removes it from the list, so go backwards.
+	for i := len(space.sleepingComponents) - 1; i >= 0; i-- {
+		space.sleepingComponents[i].Activate()
 	}
 }
 
@@ -126,11 +190,94 @@ func (space *Space) Damping() float64 {
 	return space.damping
 }
 
+// SetDamping sets the fraction of velocity every body keeps after one second. It defaults to 1, meaning no damping.
+// Bodies can add their own damping with Body.SetLinearDamping and Body.SetAngularDamping.
 func (space *Space) SetDamping(damping float64) {
 	assert(damping >= 0)
 	space.damping = damping
 }
 
+// SetSolver chooses the solver used by Step.
+//
+// With SolverSubstep each step is split into substeps of equal length. Each substep integrates velocities, runs one pass of the impulse solver,
+// and integrates positions, so stiff stacks and long chains of joints converge without raising Iterations, which it ignores.
+// The impulses reported by arbiters and constraints are then those applied during the last substep.
+// The substeps argument is ignored by SolverSequentialImpulse.
+// Contacts are found before the substeps move the bodies, so fast bodies overlap more deeply than with SolverSequentialImpulse.
+// Enabling speculative contacts with SetSpeculativeContacts avoids this.
+func (space *Space) SetSolver(solver Solver, substeps uint) {
+	assert(solver != SolverSubstep || substeps > 0, "Substep solver requires at least one substep.")
+	space.solver = solver
+	space.substeps = substeps
+}
+
+// Solver returns the solver used by Step and its number of substeps.
+func (space *Space) Solver() (Solver, uint) {
+	return space.solver, space.substeps
+}
+
+// SetSpeculativeContacts enables or disables speculative contacts.
+//
+// Normally contacts are only created once shapes overlap, so fast bodies can pass through thin shapes between steps.
+// With speculative contacts, shapes closer than the distance they could travel towards each other in a step also get contacts.
+// The solver only lets them close the gap and never pushes them apart, so they don't hover or push early.
+// Elastic shapes bounce once they would touch within the step, which can be up to a step before they meet.
+//
+// Collision handlers are called for these contacts before the shapes actually touch, with a positive ContactPointSet distance,
+// so they can reject a collision before it affects the bodies. Begin and separate are also called for shapes that pass close by
+// each other without ever touching. Check for a distance at or below 0 in pre-solve to tell when the shapes touch,
+// or use contact events, which only begin once the shapes touch.
+func (space *Space) SetSpeculativeContacts(enabled bool) {
+	space.speculative = enabled
+	space.dynamicShapes.bbfunc = space.dynamicShapeBB()
+}
+
+// dynamicShapeBB returns the bounding box function for the dynamic index.
+// Speculative contacts need shapes to be paired before their bounding boxes overlap, so they are swept along their motion.
+func (space *Space) dynamicShapeBB() SpatialIndexBB {
+	if !space.speculative {
+		return ShapeGetBB
+	}
+
+	return func(shape *Shape) BB {
+		return speculativeBB(shape, space.curr_dt)
+	}
+}
+
+func (space *Space) SpeculativeContacts() bool {
+	return space.speculative
+}
+
+// MaxLinearSpeed returns the speed limit for bodies, or 0 if there is none.
+func (space *Space) MaxLinearSpeed() float64 {
+	return space.maxLinearSpeed
+}
+
+// SetMaxLinearSpeed limits the speed of the bodies in the space, so bodies launched by large impulses don't tunnel or blow up.
+// It defaults to 0, meaning no limit. Bodies can override it with Body.SetMaxLinearSpeed.
+func (space *Space) SetMaxLinearSpeed(speed float64) {
+	assert(speed >= 0, "Speed limit must not be negative.")
+	space.maxLinearSpeed = speed
+}
+
+// MaxAngularSpeed returns the angular speed limit for bodies, or 0 if there is none.
+func (space *Space) MaxAngularSpeed() float64 {
+	return space.maxAngularSpeed
+}
+
+// SetMaxAngularSpeed limits the angular speed of the bodies in the space, in radians per second.
+// It defaults to 0, meaning no limit. Bodies can override it with Body.SetMaxAngularSpeed.
+func (space *Space) SetMaxAngularSpeed(speed float64) {
+	assert(speed >= 0, "Speed limit must not be negative.")
+	space.maxAngularSpeed = speed
+}
+
+// SetSpeedClampFunc sets a function to call when a body's velocity is clamped to its speed limits.
+// It is called while the space is locked, use AddPostStepCallback to add or remove objects.
+func (space *Space) SetSpeedClampFunc(f BodySpeedClampFunc) {
+	space.speedClampFunc = f
+}
+
 func (space *Space) SetCollisionSlop(slop float64) {
 	space.collisionSlop = slop
 }
@@ -240,6 +387,19 @@ func (space *Space) Deactivate(body *Body) {
 	}
 }
 
+// eachArbiter calls f for the space's cached arbiters, and the arbiters of sleeping bodies that Deactivate took out of the cache.
+// Arbiters between two sleeping bodies may be visited twice.
+func (space *Space) eachArbiter(f func(arb *Arbiter)) {
+	space.cachedArbiters.Each(f)
+	for _, root := range space.sleepingComponents {
+		for body := root; body != nil; body = body.sleepingNext {
+			for arb := body.arbiterList; arb != nil; arb = arb.Next(body) {
+				f(arb)
+			}
+		}
+	}
+}
+
 type PostStepCallback struct {
 	callback PostStepCallbackFunc
 	key      interface{}
@@ -258,6 +418,35 @@ func Contains(bodies []*Body, body *Body) bool {
 }
 
 func (space *Space) AddShape(shape *Shape) *Shape {
+	if space.attachShape(shape) {
+		space.staticShapes.class.Insert(shape, shape.HashId())
+	} else {
+		space.dynamicShapes.class.Insert(shape, shape.HashId())
+	}
+
+	return shape
+}
+
+// AddShapes adds a batch of shapes to the space. Bounding box trees are built from all of their leaves at once,
+// which is faster than adding the shapes one at a time and leaves the trees well balanced.
+//
+// Use it when loading large amounts of level geometry.
+func (space *Space) AddShapes(shapes []*Shape) {
+	var static, dynamic []*Shape
+	for _, shape := range shapes {
+		if space.attachShape(shape) {
+			static = append(static, shape)
+		} else {
+			dynamic = append(dynamic, shape)
+		}
+	}
+
+	insertShapes(space.staticShapes, static)
+	insertShapes(space.dynamicShapes, dynamic)
+}
+
+// attachShape prepares a shape to be inserted into one of the space's spatial indexes, and returns true if it goes into the static index.
+func (space *Space) attachShape(shape *Shape) bool {
 	var body *Body = shape.Body()
 
 	assert(shape.space != space, "You have already added this shape to this space. You must not add it a second time.")
@@ -273,15 +462,20 @@ func (space *Space) AddShape(shape *Shape) *Shape {
 	shape.SetHashId(HashValue(space.shapeIDCounter))
 	space.shapeIDCounter += 1
 	shape.Update(body.transform)
+	shape.SetSpace(space)
 
-	if isStatic {
-		space.staticShapes.class.Insert(shape, shape.HashId())
-	} else {
-		space.dynamicShapes.class.Insert(shape, shape.HashId())
+	return isStatic
+}
+
+func insertShapes(index *SpatialIndex, shapes []*Shape) {
+	if tree := index.GetTree(); tree != nil {
+		tree.InsertBatch(shapes)
+		return
 	}
-	shape.SetSpace(space)
 
-	return shape
+	for _, shape := range shapes {
+		index.class.Insert(shape, shape.HashId())
+	}
 }
 
 func (space *Space) AddBody(body *Body) *Body {
@@ -420,13 +614,22 @@ func SpaceCollideShapesFunc(obj interface{}, b *Shape, collisionId uint32, vspac
 	a := obj.(*Shape)
 	space := vspace.(*Space)
 
+	bbA, bbB := a.bb, b.bb
+	if space.speculative {
+		bbA, bbB = speculativeBB(a, space.curr_dt), speculativeBB(b, space.curr_dt)
+	}
+
 	// Reject any of the simple cases
-	if QueryReject(a, b) {
+	if queryReject(a, b, bbA, bbB) {
 		return collisionId
 	}
 
 	// Narrow-phase collision detection.
-	info := Collide(a, b, collisionId, space.ContactBufferGetArray())
+	var margin float64
+	if space.speculative && !(a.sensor || b.sensor) {
+		margin = speculativeMargin(a, b, space.curr_dt)
+	}
+	info := collide(a, b, collisionId, space.ContactBufferGetArray(), margin)
 
 	if info.count == 0 {
 		// shapes are not colliding
@@ -447,6 +650,12 @@ func SpaceCollideShapesFunc(obj interface{}, b *Shape, collisionId uint32, vspac
 	})
 	arb.Update(&info, space)
 
+	// Let objects pass through one-way shapes from behind for the lifetime of the arbiter, without calling the handler.
+	if arb.state == CP_ARBITER_STATE_FIRST_COLLISION && (arb.a.rejectsOneWay(arb.n) || arb.b.rejectsOneWay(arb.n.Neg())) {
+		arb.passThrough = true
+		arb.Ignore()
+	}
+
 	if arb.state == CP_ARBITER_STATE_FIRST_COLLISION && !arb.handler.BeginFunc(arb, space, arb.handler.UserData) {
 		arb.Ignore()
 	}
@@ -472,14 +681,44 @@ func SpaceCollideShapesFunc(obj interface{}, b *Shape, collisionId uint32, vspac
 		// However, post-solve() callbacks are not called for sensors or arbiters rejected from pre-solve.
 		if arb.state != CP_ARBITER_STATE_IGNORE {
 			arb.state = CP_ARBITER_STATE_NORMAL
+
+			if a.sensor || b.sensor {
+				space.recordSensorBegin(arb)
+			}
 		}
 	}
 
+	// Shapes may have stopped being sensors since the last step.
+	arb.setSensorOverlap((a.sensor || b.sensor) && arb.state != CP_ARBITER_STATE_IGNORE)
+
 	// Time stamp the arbiter so we know it was used recently.
 	arb.stamp = space.stamp
 	return info.collisionId
 }
 
+// speculativeReach returns how far any point on the shape could move in dt, given its body's velocity.
+func speculativeReach(shape *Shape, dt float64) (Vector, float64) {
+	body := shape.body
+	bb := shape.bb
+	radius := bb.Center().Distance(body.p) + Vector{bb.R - bb.L, bb.T - bb.B}.Length()*0.5
+	return body.v.Mult(dt), math.Abs(body.w) * radius * dt
+}
+
+// speculativeBB sweeps the shape's bounding box along its body's motion.
+func speculativeBB(shape *Shape, dt float64) BB {
+	move, turn := speculativeReach(shape, dt)
+	bb := shape.bb
+	bb = bb.Merge(bb.Offset(move))
+	return BB{bb.L - turn, bb.B - turn, bb.R + turn, bb.T + turn}
+}
+
+// speculativeMargin returns how much closer the two shapes could get in dt.
+func speculativeMargin(a, b *Shape, dt float64) float64 {
+	_, turnA := speculativeReach(a, dt)
+	_, turnB := speculativeReach(b, dt)
+	return b.body.v.Sub(a.body.v).Length()*dt + turnA + turnB
+}
+
 func (space *Space) PushFreshContactBuffer() {
 	stamp := space.stamp
 	head := space.contactBuffersHead
@@ -507,13 +746,17 @@ func (space *Space) ContactBufferGetArray() []Contact {
 }
 
 func QueryReject(a, b *Shape) bool {
+	return queryReject(a, b, a.bb, b.bb)
+}
+
+func queryReject(a, b *Shape, bbA, bbB BB) bool {
 	if a.body == b.body {
 		return true
 	}
 	if a.Filter.Reject(b.Filter) {
 		return true
 	}
-	if !a.bb.Intersects(b.bb) {
+	if !bbA.Intersects(bbB) {
 		return true
 	}
 	if QueryRejectConstraints(a.body, b.body) {
@@ -552,10 +795,15 @@ func (space *Space) ProcessComponents(dt float64) {
 				continue
 			}
 
+			bodyDvsq := dvsq
+			if body.idleSpeedThreshold != 0 {
+				bodyDvsq = body.idleSpeedThreshold * body.idleSpeedThreshold
+			}
+
 			// Need to deal with infinite mass objects
 			var keThreshold float64
-			if dvsq != 0 {
-				keThreshold = body.m * dvsq
+			if bodyDvsq != 0 {
+				keThreshold = body.m * bodyDvsq
 			}
 			if body.KineticEnergy() > keThreshold {
 				body.sleepingIdleTime = 0
@@ -609,6 +857,7 @@ func (space *Space) ProcessComponents(dt float64) {
 					for item := body; item != nil; item = item.sleepingNext {
 						space.Deactivate(item)
 					}
+					space.componentSlept(body)
 
 					// Deactivate() removed the current body from the list.
 					// Skip incrementing the index counter.
@@ -625,9 +874,44 @@ func (space *Space) ProcessComponents(dt float64) {
 	}
 }
 
+// componentSlept calls OnBodySleep for each body of a component that just fell asleep.
+func (space *Space) componentSlept(root *Body) {
+	if space.OnBodySleep == nil {
+		return
+	}
+
+	for item := root; item != nil; item = item.sleepingNext {
+		space.OnBodySleep(item)
+	}
+}
+
+// SleepingComponents returns the bodies of each sleeping component.
+// Body.IdleTime returns how long each body had been idle when it fell asleep.
+func (space *Space) SleepingComponents() [][]*Body {
+	components := make([][]*Body, 0, len(space.sleepingComponents))
+	for _, root := range space.sleepingComponents {
+		var bodies []*Body
+		for item := root; item != nil; item = item.sleepingNext {
+			bodies = append(bodies, item)
+		}
+		components = append(components, bodies)
+	}
+	return components
+}
+
+// ComponentActive returns true if any body in the component must stay awake.
+// Bodies use threshold unless they have their own sleep time threshold.
 func ComponentActive(root *Body, threshold float64) bool {
 	for item := root; item != nil; item = item.sleepingNext {
-		if item.sleepingIdleTime < threshold {
+		if !item.allowSleep {
+			return true
+		}
+
+		bodyThreshold := threshold
+		if item.sleepTimeThreshold != 0 {
+			bodyThreshold = item.sleepTimeThreshold
+		}
+		if item.sleepingIdleTime < bodyThreshold {
 			return true
 		}
 	}
@@ -696,9 +980,11 @@ func (space *Space) Step(dt float64) {
 
 	space.Lock()
 	{
-		// Integrate positions
-		for _, body := range space.dynamicBodies {
-			body.position_func(body, dt)
+		// Integrate positions. The substep solver integrates them as it goes.
+		if space.solver != SolverSubstep {
+			for _, body := range space.dynamicBodies {
+				body.position_func(body, dt)
+			}
 		}
 
 		// Find colliding pairs.
@@ -718,67 +1004,156 @@ func (space *Space) Step(dt float64) {
 			return SpaceArbiterSetFilter(arb, space)
 		})
 
-		// Prestep the arbiters and constraints.
-		slop := space.collisionSlop
-		biasCoef := 1 - math.Pow(space.collisionBias, dt)
-		for _, arbiter := range space.arbiters {
-			arbiter.PreStep(dt, slop, biasCoef)
+		if space.solver == SolverSubstep {
+			space.solveSubsteps(dt, prev_dt)
+		} else {
+			space.solveSequentialImpulse(dt, prev_dt)
 		}
 
+		// Run the constraint post-solve callbacks
 		for _, constraint := range space.constraints {
-			if constraint.PreSolve != nil {
-				constraint.PreSolve(constraint, space)
+			if constraint.PostSolve != nil {
+				constraint.PostSolve(constraint, space)
 			}
-
-			constraint.Class.PreStep(dt)
 		}
 
-		// Integrate velocities.
-		damping := math.Pow(space.damping, dt)
-		gravity := space.gravity
-		for _, body := range space.dynamicBodies {
-			body.velocity_func(body, gravity, damping, dt)
+		// run the post-solve callbacks
+		for _, arb := range space.arbiters {
+			arb.handler.PostSolveFunc(arb, space, arb.handler.UserData)
+			space.recordSolvedEvents(arb)
 		}
+	}
+	space.Unlock(true)
+}
+
+func (space *Space) solveSequentialImpulse(dt, prev_dt float64) {
+	// Prestep the arbiters and constraints.
+	slop := space.collisionSlop
+	biasCoef := 1 - math.Pow(space.collisionBias, dt)
+	for _, arbiter := range space.arbiters {
+		arbiter.PreStep(dt, slop, biasCoef)
+	}
 
-		// Apply cached impulses
-		var dt_coef float64
-		if prev_dt != 0 {
-			dt_coef = dt / prev_dt
+	for _, constraint := range space.constraints {
+		if constraint.PreSolve != nil {
+			constraint.PreSolve(constraint, space)
 		}
 
+		constraint.Class.PreStep(dt)
+	}
+
+	// Integrate velocities.
+	damping := math.Pow(space.damping, dt)
+	gravity := space.gravity
+	for _, body := range space.dynamicBodies {
+		body.velocity_func(body, gravity, damping, dt)
+	}
+
+	// Apply cached impulses
+	var dt_coef float64
+	if prev_dt != 0 {
+		dt_coef = dt / prev_dt
+	}
+
+	for _, arbiter := range space.arbiters {
+		arbiter.ApplyCachedImpulse(dt_coef)
+	}
+
+	for _, constraint := range space.constraints {
+		constraint.Class.ApplyCachedImpulse(dt_coef)
+	}
+
+	// Run the impulse solver.
+	var i uint
+	for i = 0; i < space.Iterations; i++ {
 		for _, arbiter := range space.arbiters {
-			arbiter.ApplyCachedImpulse(dt_coef)
+			arbiter.ApplyImpulse()
 		}
 
 		for _, constraint := range space.constraints {
-			constraint.Class.ApplyCachedImpulse(dt_coef)
+			constraint.Class.ApplyImpulse(dt)
+		}
+	}
+}
+
+// solveSubsteps integrates the bodies in substeps, relaxing the contacts and joints once per substep.
+func (space *Space) solveSubsteps(dt, prev_dt float64) {
+	n := space.substeps
+	h := dt / float64(n)
+
+	slop := space.collisionSlop
+	biasCoef := 1 - math.Pow(space.collisionBias, h)
+	for _, arbiter := range space.arbiters {
+		arbiter.PreStep(h, slop, biasCoef)
+	}
+
+	for _, constraint := range space.constraints {
+		if constraint.PreSolve != nil {
+			constraint.PreSolve(constraint, space)
+		}
+	}
+
+	// The velocity functions clear the forces, so save them to apply on every substep.
+	type force struct {
+		f Vector
+		t float64
+	}
+	forces := make([]force, len(space.dynamicBodies))
+	for i, body := range space.dynamicBodies {
+		forces[i] = force{body.f, body.t}
+	}
+
+	damping := math.Pow(space.damping, h)
+	gravity := space.gravity
+
+	var dt_coef float64
+	if prev_dt != 0 {
+		dt_coef = dt / prev_dt
+	}
+
+	var i uint
+	for i = 0; i < n; i++ {
+		// Integrate velocities.
+		for j, body := range space.dynamicBodies {
+			body.f, body.t = forces[j].f, forces[j].t
+			body.velocity_func(body, gravity, damping, h)
 		}
 
-		// Run the impulse solver.
-		var i uint
-		for i = 0; i < space.Iterations; i++ {
+		// Update the bias for the bodies' new positions and warm start.
+		if i == 0 {
 			for _, arbiter := range space.arbiters {
-				arbiter.ApplyImpulse()
+				arbiter.ApplyCachedImpulse(dt_coef)
 			}
-
-			for _, constraint := range space.constraints {
-				constraint.Class.ApplyImpulse(dt)
+		} else {
+			for _, arbiter := range space.arbiters {
+				arbiter.updateBias(h, slop, biasCoef)
+				arbiter.warmStart(1)
 			}
 		}
 
-		// Run the constraint post-solve callbacks
 		for _, constraint := range space.constraints {
-			if constraint.PostSolve != nil {
-				constraint.PostSolve(constraint, space)
+			constraint.Class.PreStep(h)
+			if i == 0 {
+				constraint.Class.ApplyCachedImpulse(dt_coef)
+			} else {
+				constraint.Class.ApplyCachedImpulse(1)
 			}
 		}
 
-		// run the post-solve callbacks
-		for _, arb := range space.arbiters {
-			arb.handler.PostSolveFunc(arb, space, arb.handler)
+		// Relax the contacts and joints.
+		for _, arbiter := range space.arbiters {
+			arbiter.ApplyImpulse()
+		}
+
+		for _, constraint := range space.constraints {
+			constraint.Class.ApplyImpulse(h)
+		}
+
+		// Integrate positions.
+		for _, body := range space.dynamicBodies {
+			body.position_func(body, h)
 		}
 	}
-	space.Unlock(true)
 }
 
 func (space *Space) Lock() {
@@ -857,10 +1232,12 @@ func (space *Space) LookupHandler(a, b CollisionType, defaultHandler *CollisionH
 	return defaultHandler
 }
 
+// NewCollisionHandler returns the handler for collisions between shapes with the given collision types, creating it if needed.
+// Use AddCollisionHandler to add more handlers for the same types.
 func (space *Space) NewCollisionHandler(collisionTypeA, collisionTypeB CollisionType) *CollisionHandler {
 	hash := HashPair(HashValue(collisionTypeA), HashValue(collisionTypeB))
 	handler := &CollisionHandler{collisionTypeA, collisionTypeB, DefaultBegin, DefaultPreSolve, DefaultPostSolve, DefaultSeparate, nil}
-	return space.collisionHandlers.Insert(hash, handler, func(a *CollisionHandler) *CollisionHandler { return a })
+	return mainHandler(space.collisionHandlers.Insert(hash, handler, func(a *CollisionHandler) *CollisionHandler { return a }))
 }
 
 func (space *Space) NewWildcardCollisionHandler(collisionType CollisionType) *CollisionHandler {
@@ -868,7 +1245,16 @@ func (space *Space) NewWildcardCollisionHandler(collisionType CollisionType) *Co
 
 	hash := HashPair(HashValue(collisionType), HashValue(WILDCARD_COLLISION_TYPE))
 	handler := &CollisionHandler{collisionType, WILDCARD_COLLISION_TYPE, AlwaysCollide, AlwaysCollide, DoNothing, DoNothing, nil}
-	return space.collisionHandlers.Insert(hash, handler, func(a *CollisionHandler) *CollisionHandler { return a })
+	return mainHandler(space.collisionHandlers.Insert(hash, handler, func(a *CollisionHandler) *CollisionHandler { return a }))
+}
+
+// mainHandler returns the handler for a pair of collision types from the handler registered for the pair.
+// When handlers have been added with AddCollisionHandler, the registered handler calls their chain instead.
+func mainHandler(handler *CollisionHandler) *CollisionHandler {
+	if chain, ok := handler.UserData.(*handlerChain); ok {
+		return chain.main.CollisionHandler
+	}
+	return handler
 }
 
 func (space *Space) UseWildcardDefaultHandler() {
@@ -878,9 +1264,29 @@ func (space *Space) UseWildcardDefaultHandler() {
 	}
 }
 
-func (space *Space) UseSpatialHash(dim float64, count int) {
-	staticShapes := NewSpaceHash(dim, count, ShapeGetBB, nil)
-	dynamicShapes := NewSpaceHash(dim, count, ShapeGetBB, staticShapes)
+// StaticIndex returns the spatial index that holds the static and sleeping shapes.
+func (space *Space) StaticIndex() *SpatialIndex {
+	return space.staticShapes
+}
+
+// DynamicIndex returns the spatial index that holds the active shapes.
+func (space *Space) DynamicIndex() *SpatialIndex {
+	return space.dynamicShapes
+}
+
+// UseSpatialIndex switches the space to a different spatial index for both its static and dynamic shapes.
+//
+// The factory is called twice, first for the static index with a nil staticIndex, and then for the dynamic index.
+// Any shapes already in the space are moved into the new indexes.
+func (space *Space) UseSpatialIndex(factory SpatialIndexFactory) {
+	assert(space.locked == 0, "You cannot switch spatial indexes while the space is locked.")
+
+	staticShapes := factory(ShapeGetBB, nil)
+	dynamicShapes := factory(space.dynamicShapeBB(), staticShapes)
+
+	if tree := dynamicShapes.GetTree(); tree != nil {
+		tree.velocityFunc = BBTreeVelocityFunc(ShapeVelocityFunc)
+	}
 
 	space.staticShapes.class.Each(func(shape *Shape) {
 		staticShapes.class.Insert(shape, shape.hashid)
@@ -893,6 +1299,33 @@ func (space *Space) UseSpatialHash(dim float64, count int) {
 	space.dynamicShapes = dynamicShapes
 }
 
+func (space *Space) UseSpatialHash(dim float64, count int) {
+	space.UseSpatialIndex(func(bbfunc SpatialIndexBB, staticIndex *SpatialIndex) *SpatialIndex {
+		return NewSpaceHash(dim, count, bbfunc, staticIndex)
+	})
+}
+
+// UseSweep1D switches the space to use a sweep and prune spatial index along the x axis.
+func (space *Space) UseSweep1D() {
+	space.UseSpatialIndex(NewSweep1D)
+}
+
+// UseGrid switches the space to use a uniform grid spatial index covering bounds.
+// It works best when most shapes are about the size of a cell, such as tile based worlds.
+func (space *Space) UseGrid(cellSize float64, bounds BB) {
+	space.UseSpatialIndex(func(bbfunc SpatialIndexBB, staticIndex *SpatialIndex) *SpatialIndex {
+		return NewGrid(cellSize, bounds, bbfunc, staticIndex)
+	})
+}
+
+// UseQuadTree switches the space to use a loose quadtree spatial index covering bounds.
+// It works best for large worlds where the density of shapes varies a lot.
+func (space *Space) UseQuadTree(bounds BB, maxDepth int) {
+	space.UseSpatialIndex(func(bbfunc SpatialIndexBB, staticIndex *SpatialIndex) *SpatialIndex {
+		return NewQuadTree(bounds, maxDepth, bbfunc, staticIndex)
+	})
+}
+
 func (space *Space) EachBody(f func(body *Body)) {
 	space.Lock()
 	defer space.Unlock(true)
@@ -948,6 +1381,42 @@ type PointQueryContext struct {
 	f           SpacePointQueryFunc
 }
 
+// PointQuery queries the space at a point and calls f for each shape found within maxDistance of it.
+//
+// Sensor shapes are included. A negative distance means the point is inside the shape.
+func (space *Space) PointQuery(point Vector, maxDistance float64, filter ShapeFilter, f SpacePointQueryFunc, data interface{}) {
+	context := &PointQueryContext{point, maxDistance, filter, f}
+	bb := NewBBForCircle(point, math.Max(maxDistance, 0))
+
+	space.Lock()
+	{
+		space.dynamicShapes.class.Query(context, bb, NearestPointQuery, data)
+		space.staticShapes.class.Query(context, bb, NearestPointQuery, data)
+	}
+	space.Unlock(true)
+}
+
+// PointQueryAll returns the results of PointQuery as a slice.
+func (space *Space) PointQueryAll(point Vector, maxDistance float64, filter ShapeFilter) []PointQueryInfo {
+	var infos []PointQueryInfo
+	space.PointQuery(point, maxDistance, filter, func(shape *Shape, point Vector, distance float64, gradient Vector, _ interface{}) {
+		infos = append(infos, PointQueryInfo{shape, point, distance, gradient})
+	}, nil)
+	return infos
+}
+
+func NearestPointQuery(obj interface{}, shape *Shape, collisionId uint32, data interface{}) uint32 {
+	context := obj.(*PointQueryContext)
+	if !shape.Filter.Reject(context.filter) {
+		info := shape.PointQuery(context.point)
+		if info.Shape != nil && info.Distance < context.maxDistance {
+			context.f(shape, info.Point, info.Distance, info.Gradient, data)
+		}
+	}
+
+	return collisionId
+}
+
 func (space *Space) PointQueryNearest(point Vector, maxDistance float64, filter ShapeFilter) *PointQueryInfo {
 	info := &PointQueryInfo{nil, Vector{}, maxDistance, Vector{}}
 	context := &PointQueryContext{point, maxDistance, filter, nil}
@@ -1,6 +1,9 @@
 package cp
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestSpace_ShapeQuery(t *testing.T) {
 	space := NewSpace()
@@ -42,3 +45,140 @@ func TestSpace_ReindexShape(t *testing.T) {
 		t.Errorf("got [%[1]v:%[1]T] want [%[2]v:%[2]T]", got, want)
 	}
 }
+
+func TestSpace_PointQuery(t *testing.T) {
+	space := NewSpace()
+	near := space.AddShape(NewCircle(space.StaticBody, 1, Vector{2, 0}))
+	far := space.AddShape(NewCircle(space.StaticBody, 1, Vector{-2, 0}))
+	space.AddShape(NewCircle(space.StaticBody, 1, Vector{20, 0}))
+
+	infos := space.PointQueryAll(Vector{}, 1.5, SHAPE_FILTER_ALL)
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 shapes, got %d", len(infos))
+	}
+	for _, info := range infos {
+		if info.Shape != near && info.Shape != far {
+			t.Errorf("unexpected shape %v", info.Shape)
+		}
+		if info.Distance != 1 {
+			t.Errorf("expected distance 1, got %v", info.Distance)
+		}
+	}
+
+	if infos := space.PointQueryAll(Vector{}, 0.5, SHAPE_FILTER_ALL); len(infos) != 0 {
+		t.Errorf("expected no shapes, got %d", len(infos))
+	}
+}
+
+func TestSpace_UseSpatialIndex(t *testing.T) {
+	space := newWideSpace(20)
+	count := space.DynamicIndex().Class().Count()
+
+	space.UseSpatialIndex(NewSweep1D)
+	if _, ok := space.DynamicIndex().Class().(*Sweep1D); !ok {
+		t.Fatal("expected a Sweep1D index")
+	}
+
+	space.UseSpatialIndex(NewBBTree)
+	tree := space.DynamicIndex().GetTree()
+	if tree == nil || tree.velocityFunc == nil {
+		t.Fatal("expected a BBTree with a velocity function")
+	}
+	if tree.Count() != count {
+		t.Errorf("expected %v dynamic shapes, got %v", count, tree.Count())
+	}
+	if space.StaticIndex().DynamicIndex() != space.DynamicIndex() {
+		t.Error("static index is not linked to the dynamic index")
+	}
+
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60.0)
+	}
+	space.EachBody(func(body *Body) {
+		if body.Position().Y < 0 {
+			t.Errorf("%v fell through the ground", body.Position())
+		}
+	})
+}
+
+func TestSpace_SpeculativeContacts(t *testing.T) {
+	for _, solver := range []Solver{SolverSequentialImpulse, SolverSubstep} {
+		// A small fast circle travels 50 units per step towards a thin wall.
+		bullet := func(speculative bool) float64 {
+			space := NewSpace()
+			space.SetSolver(solver, 4)
+			space.SetSpeculativeContacts(speculative)
+			space.AddShape(NewSegment(space.StaticBody, Vector{0, -50}, Vector{0, 50}, 0.5))
+
+			body := space.AddBody(NewBody(1, MomentForCircle(1, 0, 1, Vector{})))
+			body.SetPosition(Vector{-130, 0})
+			body.SetVelocity(3000, 0)
+			space.AddShape(NewCircle(body, 1, Vector{}))
+
+			for i := 0; i < 30; i++ {
+				space.Step(1.0 / 60.0)
+			}
+			return body.Position().X
+		}
+
+		if x := bullet(false); x < 0 {
+			t.Errorf("solver %v: expected the circle to tunnel without speculative contacts, got x %v", solver, x)
+		}
+		if x := bullet(true); x > -1.5 || x < -2 {
+			t.Errorf("solver %v: expected the circle to stop at the wall, got x %v", solver, x)
+		}
+	}
+}
+
+func TestSpace_SpeculativeContactsBounce(t *testing.T) {
+	for _, solver := range []Solver{SolverSequentialImpulse, SolverSubstep} {
+		space := NewSpace()
+		space.SetGravity(Vector{0, -100})
+		space.SetSolver(solver, 4)
+		space.SetSpeculativeContacts(true)
+		space.AddShape(NewSegment(space.StaticBody, Vector{-50, 0}, Vector{50, 0}, 0)).SetElasticity(1)
+
+		ball := space.AddBody(NewBody(1, MomentForCircle(1, 0, 1, Vector{})))
+		ball.SetPosition(Vector{0, 50})
+		space.AddShape(NewCircle(ball, 1, Vector{})).SetElasticity(1)
+
+		bounced := false
+		height := 0.0
+		for i := 0; i < 200; i++ {
+			space.Step(1.0 / 60.0)
+			if ball.Velocity().Y > 0 {
+				bounced = true
+			}
+			if bounced {
+				height = math.Max(height, ball.Position().Y)
+			}
+		}
+
+		if height < 45 {
+			t.Errorf("solver %v: expected the ball to bounce back up, got height %v", solver, height)
+		}
+	}
+}
+
+func TestSpace_SpeculativeContactsRest(t *testing.T) {
+	for _, solver := range []Solver{SolverSequentialImpulse, SolverSubstep} {
+		space := NewSpace()
+		space.SetGravity(Vector{0, -100})
+		space.SetSolver(solver, 4)
+		space.SetSpeculativeContacts(true)
+		space.AddShape(NewSegment(space.StaticBody, Vector{-50, 0}, Vector{50, 0}, 0))
+
+		box := space.AddBody(NewBody(1, MomentForBox(1, 10, 10)))
+		box.SetPosition(Vector{0, 20})
+		space.AddShape(NewBox(box, 10, 10, 0))
+
+		for i := 0; i < 200; i++ {
+			space.Step(1.0 / 60.0)
+		}
+
+		// Resting on the ground, not hovering above it or sinking past the slop.
+		if y := box.Position().Y; math.Abs(y-5) > space.collisionSlop {
+			t.Errorf("solver %v: expected the box to rest on the ground, got y %v", solver, y)
+		}
+	}
+}
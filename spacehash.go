@@ -103,7 +103,7 @@ func (hash *SpaceHash) Remove(obj *Shape, hashId HashValue) {
 func (hash *SpaceHash) Reindex() {
 	hash.clearTable()
 	hash.handleSet.Each(func(hand *Handle) {
-		hash.bbfunc(hand.obj)
+		hash.hashHandle(hand, hash.bbfunc(hand.obj))
 	})
 }
 
@@ -204,6 +204,20 @@ func (hash *SpaceHash) ReindexQuery(f SpatialIndexQuery, data interface{}) {
 
 func (hash *SpaceHash) Query(obj interface{}, bb BB, f SpatialIndexQuery, data interface{}) {
 	dim := hash.celldim
+
+	// Visiting more cells than the table has would be slower than visiting every object,
+	// and the cell coordinates of huge or infinite bounding boxes don't fit in an int.
+	cols := math.Floor(bb.R/dim) - math.Floor(bb.L/dim) + 1
+	rows := math.Floor(bb.T/dim) - math.Floor(bb.B/dim) + 1
+	if !(cols*rows <= float64(hash.numCells)) {
+		hash.handleSet.Each(func(hand *Handle) {
+			if hand.obj != obj {
+				f(obj, hand.obj, 0, data)
+			}
+		})
+		return
+	}
+
 	l := floor(bb.L / dim)
 	r := floor(bb.R / dim)
 	b := floor(bb.B / dim)
@@ -286,16 +300,13 @@ func (hash *SpaceHash) SegmentQuery(obj interface{}, a, b Vector, t_exit float64
 		dtdy = INFINITY
 	}
 
-	var nextH, nextV float64
-	if tempH != 0 {
+	// A segment starting on a cell boundary going in the negative direction enters the neighbouring cell at t = 0.
+	nextH, nextV := INFINITY, INFINITY
+	if dx != 0 {
 		nextH = tempH * dtdx
-	} else {
-		nextH = dtdx
 	}
-	if tempV != 0 {
+	if dy != 0 {
 		nextV = tempV * dtdy
-	} else {
-		nextV = dtdy
 	}
 
 	for t < t_exit {
@@ -5,7 +5,10 @@ type SpatialIndexIterator func(obj *Shape)
 type SpatialIndexQuery func(obj1 interface{}, obj2 *Shape, collisionId uint32, data interface{}) uint32
 type SpatialIndexSegmentQuery func(obj1 interface{}, obj2 *Shape, data interface{}) float64
 
-// SpatialIndexer implemented by BBTree
+// SpatialIndexer is the interface implemented by broad phase collision detection algorithms such as BBTree and SpaceHash.
+//
+// Implementations are wrapped in a SpatialIndex with NewSpatialIndex, and can be installed in a space with Space.UseSpatialIndex.
+// The spatialindextest package contains a conformance suite for checking new implementations.
 type SpatialIndexer interface {
 	Count() int
 	Each(f SpatialIndexIterator)
@@ -29,6 +32,9 @@ type SpatialIndex struct {
 	staticIndex, dynamicIndex *SpatialIndex
 }
 
+// SpatialIndexFactory creates a spatial index. See Space.UseSpatialIndex.
+type SpatialIndexFactory func(bbfunc SpatialIndexBB, staticIndex *SpatialIndex) *SpatialIndex
+
 func NewSpatialIndex(klass SpatialIndexer, bbfunc SpatialIndexBB, staticIndex *SpatialIndex) *SpatialIndex {
 	index := &SpatialIndex{
 		class:       klass,
@@ -43,18 +49,41 @@ func NewSpatialIndex(klass SpatialIndexer, bbfunc SpatialIndexBB, staticIndex *S
 	return index
 }
 
+// Class returns the SpatialIndexer that implements the index.
+func (index *SpatialIndex) Class() SpatialIndexer {
+	return index.class
+}
+
+// BB returns the bounding box of obj using the index's bounding box function.
+func (index *SpatialIndex) BB(obj *Shape) BB {
+	return index.bbfunc(obj)
+}
+
+// StaticIndex returns the static index this index collides against in ReindexQuery, if any.
+func (index *SpatialIndex) StaticIndex() *SpatialIndex {
+	return index.staticIndex
+}
+
+// DynamicIndex returns the dynamic index that collides against this one, if any.
+func (index *SpatialIndex) DynamicIndex() *SpatialIndex {
+	return index.dynamicIndex
+}
+
+// GetTree returns the index as a BBTree, or nil if it is some other kind of index.
 func (index *SpatialIndex) GetTree() *BBTree {
 	if index == nil {
 		return nil
 	}
-	return index.class.(*BBTree)
+	tree, _ := index.class.(*BBTree)
+	return tree
 }
 
 func (index *SpatialIndex) GetRootIfTree() *Node {
-	if index == nil {
+	tree := index.GetTree()
+	if tree == nil {
 		return nil
 	}
-	return index.class.(*BBTree).root
+	return tree.root
 }
 
 func (dynamicIndex *SpatialIndex) CollideStatic(staticIndex *SpatialIndex, f SpatialIndexQuery, data interface{}) {
@@ -0,0 +1,56 @@
+package spatialindextest
+
+import (
+	"testing"
+
+	"github.com/undefinedopcode/cp/v2"
+)
+
+// Benchmark runs the same broad phase benchmarks against the indexes made by factory, so they can be compared:
+//
+//	func BenchmarkMyIndex(b *testing.B) {
+//		spatialindextest.Benchmark(b, NewMyIndex)
+//	}
+//
+// Small moving objects are spread evenly over [0, 1000] in Uniform, and gathered into a few dense groups in Clustered.
+func Benchmark(b *testing.B, factory cp.SpatialIndexFactory) {
+	b.Run("Uniform", func(b *testing.B) { benchmarkReindexQuery(b, factory, 0) })
+	b.Run("Clustered", func(b *testing.B) { benchmarkReindexQuery(b, factory, 5) })
+}
+
+// benchmarkReindexQuery moves the objects and runs ReindexQuery once per iteration, like a space step.
+func benchmarkReindexQuery(b *testing.B, factory cp.SpatialIndexFactory, clusters int) {
+	w := newWorld()
+	index := factory(w.bbfunc, nil)
+
+	var centers []cp.Vector
+	for i := 0; i < clusters; i++ {
+		centers = append(centers, cp.Vector{X: 100 + w.rng.Float64()*800, Y: 100 + w.rng.Float64()*800})
+	}
+
+	objs := make([]*cp.Shape, 2000)
+	velocities := make([]cp.Vector, len(objs))
+	for i := range objs {
+		var p cp.Vector
+		if clusters > 0 {
+			p = centers[i%clusters].Add(cp.Vector{X: w.rng.Float64()*80 - 40, Y: w.rng.Float64()*80 - 40})
+		} else {
+			p = cp.Vector{X: w.rng.Float64() * 1000, Y: w.rng.Float64() * 1000}
+		}
+
+		objs[i] = w.newObject()
+		w.bbs[objs[i]] = cp.NewBBForExtents(p, 2, 2)
+		velocities[i] = cp.Vector{X: w.rng.Float64()*0.4 - 0.2, Y: w.rng.Float64()*0.4 - 0.2}
+		index.Class().Insert(objs[i], objs[i].HashId())
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i, obj := range objs {
+			w.bbs[obj] = w.bbs[obj].Offset(velocities[i])
+		}
+		index.Class().ReindexQuery(func(_ interface{}, _ *cp.Shape, collisionId uint32, _ interface{}) uint32 {
+			return collisionId
+		}, nil)
+	}
+}
@@ -0,0 +1,367 @@
+// Package spatialindextest implements a conformance suite for cp.SpatialIndexer implementations.
+//
+// A custom broad phase can be checked from a regular test:
+//
+//	func TestMyIndex(t *testing.T) {
+//		spatialindextest.Run(t, NewMyIndex)
+//	}
+//
+// The suite checks the contract the space relies on. Indexes may report extra candidate pairs whose bounding boxes don't overlap,
+// since the narrow phase filters those out, but they must never miss an overlapping pair or report the same pair twice in one pass.
+//
+// Benchmark runs the same broad phase benchmarks against any index.
+package spatialindextest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/undefinedopcode/cp/v2"
+)
+
+// world holds the objects for a test along with their bounding boxes.
+type world struct {
+	rng    *rand.Rand
+	bbs    map[*cp.Shape]cp.BB
+	body   *cp.Body
+	nextId cp.HashValue
+}
+
+func newWorld() *world {
+	return &world{
+		rng:    rand.New(rand.NewSource(1)),
+		bbs:    map[*cp.Shape]cp.BB{},
+		body:   cp.NewStaticBody(),
+		nextId: 1,
+	}
+}
+
+func (w *world) bbfunc(obj *cp.Shape) cp.BB {
+	return w.bbs[obj]
+}
+
+// randomBB returns a box somewhere in [-500, 1500], with the occasional large one.
+func (w *world) randomBB() cp.BB {
+	size := 5 + w.rng.Float64()*55
+	if w.rng.Intn(20) == 0 {
+		size = 300
+	}
+	x := -500 + w.rng.Float64()*2000
+	y := -500 + w.rng.Float64()*2000
+	return cp.NewBB(x, y, x+size, y+size*(0.5+w.rng.Float64()))
+}
+
+func (w *world) newObject() *cp.Shape {
+	obj := cp.NewCircle(w.body, 1, cp.Vector{})
+	obj.SetHashId(w.nextId)
+	w.nextId++
+	w.bbs[obj] = w.randomBB()
+	return obj
+}
+
+func (w *world) insert(index *cp.SpatialIndex, count int) []*cp.Shape {
+	objs := make([]*cp.Shape, count)
+	for i := range objs {
+		objs[i] = w.newObject()
+		index.Class().Insert(objs[i], objs[i].HashId())
+	}
+	return objs
+}
+
+type pair struct {
+	a, b *cp.Shape
+}
+
+func makePair(a, b *cp.Shape) pair {
+	if a.HashId() > b.HashId() {
+		a, b = b, a
+	}
+	return pair{a, b}
+}
+
+// Run runs the conformance suite against the indexes made by factory.
+func Run(t *testing.T, factory cp.SpatialIndexFactory) {
+	t.Run("InsertRemove", func(t *testing.T) { testInsertRemove(t, factory) })
+	t.Run("Query", func(t *testing.T) { testQuery(t, factory) })
+	t.Run("SegmentQuery", func(t *testing.T) { testSegmentQuery(t, factory) })
+	t.Run("Reindex", func(t *testing.T) { testReindex(t, factory) })
+	t.Run("ReindexQuery", func(t *testing.T) { testReindexQuery(t, factory) })
+	t.Run("ReindexQueryStatic", func(t *testing.T) { testReindexQueryStatic(t, factory) })
+}
+
+func testInsertRemove(t *testing.T, factory cp.SpatialIndexFactory) {
+	w := newWorld()
+	index := factory(w.bbfunc, nil)
+	class := index.Class()
+
+	objs := w.insert(index, 200)
+	if class.Count() != len(objs) {
+		t.Fatalf("expected Count() to be %v, got %v", len(objs), class.Count())
+	}
+
+	seen := map[*cp.Shape]int{}
+	class.Each(func(obj *cp.Shape) {
+		seen[obj]++
+	})
+	for _, obj := range objs {
+		if seen[obj] != 1 {
+			t.Errorf("Each() visited an object %v times", seen[obj])
+		}
+		if !class.Contains(obj, obj.HashId()) {
+			t.Errorf("Contains() is false for an inserted object")
+		}
+	}
+
+	for _, obj := range objs[:100] {
+		class.Remove(obj, obj.HashId())
+	}
+	if class.Count() != 100 {
+		t.Fatalf("expected Count() to be 100 after removing, got %v", class.Count())
+	}
+	for _, obj := range objs[:100] {
+		if class.Contains(obj, obj.HashId()) {
+			t.Errorf("Contains() is true for a removed object")
+		}
+	}
+	class.Each(func(obj *cp.Shape) {
+		if seen[obj] == 0 || !class.Contains(obj, obj.HashId()) {
+			t.Errorf("Each() visited an object that is not in the index")
+		}
+	})
+}
+
+// checkQuery checks that Query reports every object overlapping bb exactly once.
+func checkQuery(t *testing.T, w *world, index *cp.SpatialIndex, live map[*cp.Shape]bool, bb cp.BB) {
+	t.Helper()
+
+	found := map[*cp.Shape]int{}
+	index.Class().Query(nil, bb, func(_ interface{}, obj *cp.Shape, collisionId uint32, _ interface{}) uint32 {
+		found[obj]++
+		return collisionId
+	}, nil)
+
+	for obj, count := range found {
+		if !live[obj] {
+			t.Errorf("Query() reported an object that is not in the index")
+		} else if count > 1 {
+			t.Errorf("Query() reported an object %v times", count)
+		}
+	}
+	for obj := range live {
+		if w.bbs[obj].Intersects(bb) && found[obj] == 0 {
+			t.Errorf("Query(%v) missed an object at %v", bb, w.bbs[obj])
+		}
+	}
+}
+
+func liveSet(objs []*cp.Shape) map[*cp.Shape]bool {
+	live := map[*cp.Shape]bool{}
+	for _, obj := range objs {
+		live[obj] = true
+	}
+	return live
+}
+
+func testQuery(t *testing.T, factory cp.SpatialIndexFactory) {
+	w := newWorld()
+	index := factory(w.bbfunc, nil)
+
+	objs := w.insert(index, 300)
+	for _, obj := range objs[:50] {
+		index.Class().Remove(obj, obj.HashId())
+	}
+	live := liveSet(objs[50:])
+
+	for i := 0; i < 50; i++ {
+		checkQuery(t, w, index, live, w.randomBB())
+	}
+	// Something much larger than the objects, and something outside of all of them.
+	checkQuery(t, w, index, live, cp.NewBB(-1000, -1000, 2000, 2000))
+	checkQuery(t, w, index, live, cp.NewBB(5000, 5000, 5001, 5001))
+	// An unbounded query, like the bounding box of an infinite segment.
+	checkQuery(t, w, index, live, cp.NewBB(-cp.INFINITY, -cp.INFINITY, cp.INFINITY, cp.INFINITY))
+	checkQuery(t, w, index, live, cp.NewBB(100, -cp.INFINITY, 200, cp.INFINITY))
+}
+
+func testSegmentQuery(t *testing.T, factory cp.SpatialIndexFactory) {
+	w := newWorld()
+	index := factory(w.bbfunc, nil)
+
+	objs := w.insert(index, 300)
+	live := liveSet(objs)
+
+	for i := 0; i < 50; i++ {
+		a := w.randomBB().Center()
+		b := w.randomBB().Center()
+		if i%10 == 0 {
+			// axis aligned segments hit the edge cases in grid traversals
+			b.Y = a.Y
+		}
+		if i%10 == 5 || i%10 == 6 {
+			// so do segments starting on a cell boundary, for any cell size dividing 240,
+			// with an object where they end part way into the last cell
+			var end cp.BB
+			if i%10 == 5 {
+				a.X = math.Round(a.X/240) * 240
+				b.X, b.Y = a.X-247, a.Y
+				end = cp.NewBB(b.X+1, b.Y-1, b.X+3, b.Y+1)
+			} else {
+				a.Y = math.Round(a.Y/240) * 240
+				b.X, b.Y = a.X, a.Y-247
+				end = cp.NewBB(b.X-1, b.Y+1, b.X+1, b.Y+3)
+			}
+
+			obj := w.newObject()
+			w.bbs[obj] = end
+			index.Class().Insert(obj, obj.HashId())
+			objs = append(objs, obj)
+			live[obj] = true
+		}
+
+		found := map[*cp.Shape]int{}
+		index.Class().SegmentQuery(nil, a, b, 1, func(_ interface{}, obj *cp.Shape, _ interface{}) float64 {
+			found[obj]++
+			return 1
+		}, nil)
+
+		for obj, count := range found {
+			if !live[obj] {
+				t.Errorf("SegmentQuery() reported an object that is not in the index")
+			} else if count > 1 {
+				t.Errorf("SegmentQuery() reported an object %v times", count)
+			}
+		}
+		for _, obj := range objs {
+			if w.bbs[obj].IntersectsSegment(a, b) && found[obj] == 0 {
+				t.Errorf("SegmentQuery(%v, %v) missed an object at %v", a, b, w.bbs[obj])
+			}
+		}
+	}
+}
+
+func testReindex(t *testing.T, factory cp.SpatialIndexFactory) {
+	w := newWorld()
+	index := factory(w.bbfunc, nil)
+
+	objs := w.insert(index, 200)
+	live := liveSet(objs)
+
+	for _, obj := range objs {
+		w.bbs[obj] = w.randomBB()
+	}
+	index.Class().Reindex()
+	for i := 0; i < 20; i++ {
+		checkQuery(t, w, index, live, w.randomBB())
+	}
+
+	for _, obj := range objs[:20] {
+		w.bbs[obj] = w.randomBB()
+		index.Class().ReindexObject(obj, obj.HashId())
+	}
+	for i := 0; i < 20; i++ {
+		checkQuery(t, w, index, live, w.randomBB())
+	}
+	for _, obj := range objs[:20] {
+		checkQuery(t, w, index, live, w.bbs[obj])
+	}
+}
+
+// checkReindexQuery runs ReindexQuery and checks that every overlapping pair is reported exactly once.
+// Pairs where both objects are static must not be reported at all.
+func checkReindexQuery(t *testing.T, w *world, index *cp.SpatialIndex, dynamic, static []*cp.Shape) {
+	t.Helper()
+
+	isStatic := liveSet(static)
+	live := liveSet(append(append([]*cp.Shape{}, dynamic...), static...))
+
+	found := map[pair]int{}
+	index.Class().ReindexQuery(func(obj1 interface{}, obj2 *cp.Shape, collisionId uint32, _ interface{}) uint32 {
+		a := obj1.(*cp.Shape)
+		if a == obj2 {
+			t.Errorf("ReindexQuery() reported an object colliding with itself")
+		} else if !live[a] || !live[obj2] {
+			t.Errorf("ReindexQuery() reported an object that is not in the index")
+		} else if isStatic[a] && isStatic[obj2] {
+			t.Errorf("ReindexQuery() reported a pair of static objects")
+		}
+		found[makePair(a, obj2)]++
+		return collisionId
+	}, nil)
+
+	for p, count := range found {
+		if count > 1 {
+			t.Errorf("ReindexQuery() reported a pair %v times", count)
+			delete(found, p)
+		}
+	}
+
+	for i, a := range dynamic {
+		for _, b := range dynamic[i+1:] {
+			if w.bbs[a].Intersects(w.bbs[b]) && found[makePair(a, b)] == 0 {
+				t.Errorf("ReindexQuery() missed a pair at %v and %v", w.bbs[a], w.bbs[b])
+			}
+		}
+		for _, b := range static {
+			if w.bbs[a].Intersects(w.bbs[b]) && found[makePair(a, b)] == 0 {
+				t.Errorf("ReindexQuery() missed a static pair at %v and %v", w.bbs[a], w.bbs[b])
+			}
+		}
+	}
+}
+
+func testReindexQuery(t *testing.T, factory cp.SpatialIndexFactory) {
+	w := newWorld()
+	index := factory(w.bbfunc, nil)
+
+	objs := w.insert(index, 300)
+	checkReindexQuery(t, w, index, objs, nil)
+
+	// Nothing moved.
+	checkReindexQuery(t, w, index, objs, nil)
+
+	// Move some of the objects a little and some of them a lot.
+	for i, obj := range objs[:100] {
+		if i%2 == 0 {
+			w.bbs[obj] = w.bbs[obj].Offset(cp.Vector{X: 3, Y: -2})
+		} else {
+			w.bbs[obj] = w.randomBB()
+		}
+	}
+	checkReindexQuery(t, w, index, objs, nil)
+
+	for _, obj := range objs[:50] {
+		index.Class().Remove(obj, obj.HashId())
+	}
+	checkReindexQuery(t, w, index, objs[50:], nil)
+}
+
+func testReindexQueryStatic(t *testing.T, factory cp.SpatialIndexFactory) {
+	w := newWorld()
+	staticIndex := factory(w.bbfunc, nil)
+	dynamicIndex := factory(w.bbfunc, staticIndex)
+
+	// Insert static objects both before and after the dynamic ones.
+	static := w.insert(staticIndex, 100)
+	dynamic := w.insert(dynamicIndex, 200)
+	static = append(static, w.insert(staticIndex, 100)...)
+	checkReindexQuery(t, w, dynamicIndex, dynamic, static)
+	checkReindexQuery(t, w, dynamicIndex, dynamic, static)
+
+	for _, obj := range dynamic[:50] {
+		w.bbs[obj] = w.randomBB()
+	}
+	checkReindexQuery(t, w, dynamicIndex, dynamic, static)
+
+	// Move objects between the indexes, like a body falling asleep and waking up.
+	for _, obj := range dynamic[:20] {
+		dynamicIndex.Class().Remove(obj, obj.HashId())
+		staticIndex.Class().Insert(obj, obj.HashId())
+	}
+	for _, obj := range static[:20] {
+		staticIndex.Class().Remove(obj, obj.HashId())
+		dynamicIndex.Class().Insert(obj, obj.HashId())
+	}
+	dynamic, static = append(dynamic[20:], static[:20]...), append(static[20:], dynamic[:20]...)
+	checkReindexQuery(t, w, dynamicIndex, dynamic, static)
+}
@@ -0,0 +1,91 @@
+package cp
+
+// stepperTolerance is the fraction of a time step treated as rounding error.
+const stepperTolerance = 1e-9
+
+// Stepper advances a space with a fixed time step, however long each frame takes.
+//
+// Frame deltas are added to an accumulator and whole steps are run out of it, so the simulation behaves the same at any frame rate.
+// The time left over in the accumulator is exposed as Alpha() so bodies can be drawn with Body.InterpolatedTransform().
+//
+//	stepper := cp.NewStepper(space, 1.0/60.0, 5)
+//	for {
+//		stepper.Update(frameDelta)
+//		alpha := stepper.Alpha()
+//		space.EachBody(func(body *cp.Body) {
+//			draw(body.InterpolatedTransform(alpha))
+//		})
+//	}
+type Stepper struct {
+	space             *Space
+	dt                float64
+	maxStepsPerUpdate int
+
+	accumulator float64
+}
+
+// NewStepper creates a Stepper that steps space by dt.
+//
+// At most maxStepsPerUpdate steps are run per Update. Time beyond that is dropped,
+// which keeps a slow frame from causing even slower frames as the simulation tries to catch up.
+func NewStepper(space *Space, dt float64, maxStepsPerUpdate int) *Stepper {
+	assert(dt > 0, "Stepper time step must be positive.")
+	assert(maxStepsPerUpdate > 0, "Stepper must allow at least one step per update.")
+
+	return &Stepper{
+		space:             space,
+		dt:                dt,
+		maxStepsPerUpdate: maxStepsPerUpdate,
+	}
+}
+
+func (stepper *Stepper) Space() *Space {
+	return stepper.space
+}
+
+// TimeStep returns the fixed time step.
+func (stepper *Stepper) TimeStep() float64 {
+	return stepper.dt
+}
+
+// Update adds delta seconds to the accumulator and runs as many fixed steps as fit. It returns the number of steps run.
+func (stepper *Stepper) Update(delta float64) int {
+	stepper.accumulator += delta
+
+	maxTime := float64(stepper.maxStepsPerUpdate) * stepper.dt
+	if stepper.accumulator > maxTime {
+		stepper.accumulator = maxTime
+	}
+
+	// Allow for rounding error so deltas that add up to whole steps run all of them.
+	steps := 0
+	for steps < stepper.maxStepsPerUpdate && stepper.accumulator >= stepper.dt*(1-stepperTolerance) {
+		stepper.step()
+		stepper.accumulator -= stepper.dt
+		steps++
+	}
+	if stepper.accumulator < 0 {
+		stepper.accumulator = 0
+	}
+
+	return steps
+}
+
+func (stepper *Stepper) step() {
+	space := stepper.space
+
+	// Remember where the bodies were so they can be interpolated.
+	stamp := space.stamp + 1
+	for _, body := range space.dynamicBodies {
+		body.prevP = body.p
+		body.prevA = body.a
+		body.prevStamp = stamp
+	}
+
+	space.Step(stepper.dt)
+}
+
+// Alpha returns how far the accumulated time is between the last step and the next one, from 0 to 1.
+func (stepper *Stepper) Alpha() float64 {
+	return stepper.accumulator / stepper.dt
+}
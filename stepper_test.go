@@ -0,0 +1,55 @@
+package cp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStepper(t *testing.T) {
+	space := NewSpace()
+	stepper := NewStepper(space, 0.01, 4)
+
+	body := space.AddBody(NewBody(1, 1))
+	body.SetVelocity(100, 0)
+	body.SetAngularVelocity(1)
+
+	// A brand new body has nothing to interpolate from.
+	if got := body.InterpolatedTransform(0.5).Point(Vector{}); got != body.Position() {
+		t.Errorf("expected the current position, got %v", got)
+	}
+
+	if steps := stepper.Update(0.025); steps != 2 {
+		t.Fatalf("expected 2 steps, got %v", steps)
+	}
+	if alpha := stepper.Alpha(); math.Abs(alpha-0.5) > 1e-9 {
+		t.Errorf("expected alpha of 0.5, got %v", alpha)
+	}
+	if !body.Position().Near(Vector{2, 0}, 1e-9) {
+		t.Errorf("expected the body at (2, 0), got %v", body.Position())
+	}
+
+	transform := body.InterpolatedTransform(stepper.Alpha())
+	if p := transform.Point(Vector{}); !p.Near(Vector{1.5, 0}, 1e-9) {
+		t.Errorf("expected to interpolate to (1.5, 0), got %v", p)
+	}
+	if angle := transform.Vect(Vector{1, 0}).ToAngle(); math.Abs(angle-0.015) > 1e-9 {
+		t.Errorf("expected to interpolate to an angle of 0.015, got %v", angle)
+	}
+	if body.InterpolatedTransform(1) != body.transform {
+		t.Error("expected an alpha of 1 to give the current transform")
+	}
+
+	// The time beyond maxStepsPerUpdate is dropped.
+	if steps := stepper.Update(1); steps != 4 {
+		t.Errorf("expected 4 steps, got %v", steps)
+	}
+	if alpha := stepper.Alpha(); alpha > 1e-9 {
+		t.Errorf("expected the accumulator to be empty, got an alpha of %v", alpha)
+	}
+
+	// Stepping the space directly leaves nothing to interpolate.
+	space.Step(0.01)
+	if body.InterpolatedTransform(0) != body.transform {
+		t.Error("expected the current transform after a direct Step")
+	}
+}
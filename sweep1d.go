@@ -0,0 +1,137 @@
+package cp
+
+import "sort"
+
+// Sweep1D is a sweep and prune spatial index along the x axis.
+//
+// Objects are kept sorted by the left edge of their bounding boxes, and pairs are found by sweeping over the sorted list.
+// It works best for scenes that are spread out along the x axis, such as side scrollers.
+type Sweep1D struct {
+	*SpatialIndex
+
+	table []sweepCell
+}
+
+type sweepBounds struct {
+	min, max float64
+}
+
+func (a sweepBounds) overlaps(b sweepBounds) bool {
+	return a.min <= b.max && b.min <= a.max
+}
+
+type sweepCell struct {
+	obj    *Shape
+	bounds sweepBounds
+}
+
+func NewSweep1D(bbfunc SpatialIndexBB, staticIndex *SpatialIndex) *SpatialIndex {
+	sweep := &Sweep1D{}
+	spatialIndex := NewSpatialIndex(sweep, bbfunc, staticIndex)
+	sweep.SpatialIndex = spatialIndex
+	return spatialIndex
+}
+
+func (sweep *Sweep1D) bbToBounds(bb BB) sweepBounds {
+	return sweepBounds{bb.L, bb.R}
+}
+
+func (sweep *Sweep1D) makeCell(obj *Shape) sweepCell {
+	return sweepCell{obj, sweep.bbToBounds(sweep.bbfunc(obj))}
+}
+
+func (sweep *Sweep1D) find(obj *Shape) int {
+	for i := range sweep.table {
+		if sweep.table[i].obj == obj {
+			return i
+		}
+	}
+	return -1
+}
+
+func (sweep *Sweep1D) Count() int {
+	return len(sweep.table)
+}
+
+func (sweep *Sweep1D) Each(f SpatialIndexIterator) {
+	for i := 0; i < len(sweep.table); i++ {
+		f(sweep.table[i].obj)
+	}
+}
+
+func (sweep *Sweep1D) Contains(obj *Shape, hashId HashValue) bool {
+	return sweep.find(obj) != -1
+}
+
+func (sweep *Sweep1D) Insert(obj *Shape, hashId HashValue) {
+	sweep.table = append(sweep.table, sweep.makeCell(obj))
+}
+
+func (sweep *Sweep1D) Remove(obj *Shape, hashId HashValue) {
+	i := sweep.find(obj)
+	if i == -1 {
+		return
+	}
+
+	// leak-free delete from slice
+	last := len(sweep.table) - 1
+	sweep.table[i] = sweep.table[last]
+	sweep.table[last] = sweepCell{}
+	sweep.table = sweep.table[:last]
+}
+
+func (sweep *Sweep1D) Reindex() {
+	for i := range sweep.table {
+		sweep.table[i] = sweep.makeCell(sweep.table[i].obj)
+	}
+}
+
+func (sweep *Sweep1D) ReindexObject(obj *Shape, hashId HashValue) {
+	i := sweep.find(obj)
+	if i != -1 {
+		sweep.table[i] = sweep.makeCell(obj)
+	}
+}
+
+func (sweep *Sweep1D) ReindexQuery(f SpatialIndexQuery, data interface{}) {
+	table := sweep.table
+
+	// Update bounds and sort
+	for i := range table {
+		table[i] = sweep.makeCell(table[i].obj)
+	}
+	sort.Slice(table, func(i, j int) bool {
+		return table[i].bounds.min < table[j].bounds.min
+	})
+
+	for i, cell := range table {
+		max := cell.bounds.max
+		for j := i + 1; j < len(table) && table[j].bounds.min <= max; j++ {
+			f(cell.obj, table[j].obj, 0, data)
+		}
+	}
+
+	// Reindex query is also responsible for colliding against the static index.
+	sweep.CollideStatic(sweep.staticIndex, f, data)
+}
+
+func (sweep *Sweep1D) Query(obj interface{}, bb BB, f SpatialIndexQuery, data interface{}) {
+	bounds := sweep.bbToBounds(bb)
+	for i := 0; i < len(sweep.table); i++ {
+		cell := sweep.table[i]
+		if cell.bounds.overlaps(bounds) {
+			f(obj, cell.obj, 0, data)
+		}
+	}
+}
+
+func (sweep *Sweep1D) SegmentQuery(obj interface{}, a, b Vector, t_exit float64, f SpatialIndexSegmentQuery, data interface{}) {
+	bb := NewBB(a.X, a.Y, a.X, a.Y).Expand(b)
+	bounds := sweep.bbToBounds(bb)
+	for i := 0; i < len(sweep.table); i++ {
+		cell := sweep.table[i]
+		if cell.bounds.overlaps(bounds) {
+			f(obj, cell.obj, data)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package cp
+
+import "testing"
+
+// newWideSpace builds a long, flat scene of boxes resting on a row of ground segments.
+func newWideSpace(count int) *Space {
+	space := NewSpace()
+	space.SetGravity(Vector{0, -100})
+
+	width := float64(count) * 3
+	for x := 0.0; x < width; x += 50 {
+		space.AddShape(NewSegment(space.StaticBody, Vector{x, 0}, Vector{x + 50, 0}, 1))
+	}
+
+	for i := 0; i < count; i++ {
+		body := space.AddBody(NewBody(1, MomentForBox(1, 2, 2)))
+		body.SetPosition(Vector{float64(i)*3 + 1.5, 2 + float64(i%3)*3})
+		space.AddShape(NewBox(body, 2, 2, 0))
+	}
+
+	return space
+}
+
+func TestSweep1D(t *testing.T) {
+	space := newWideSpace(50)
+	space.UseSweep1D()
+
+	for i := 0; i < 120; i++ {
+		space.Step(1.0 / 60.0)
+	}
+
+	space.EachBody(func(body *Body) {
+		if body.GetType() == BODY_DYNAMIC && body.Position().Y < 0 {
+			t.Errorf("%v fell through the ground", body)
+		}
+	})
+}
+
+func benchmarkWideSpace(b *testing.B, use func(space *Space)) {
+	space := newWideSpace(1000)
+	use(space)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		space.Step(1.0 / 60.0)
+	}
+}
+
+func BenchmarkWideSpace_BBTree(b *testing.B) {
+	benchmarkWideSpace(b, func(space *Space) {})
+}
+
+func BenchmarkWideSpace_SpaceHash(b *testing.B) {
+	benchmarkWideSpace(b, func(space *Space) {
+		space.UseSpatialHash(4, 4000)
+	})
+}
+
+func BenchmarkWideSpace_Sweep1D(b *testing.B) {
+	benchmarkWideSpace(b, func(space *Space) {
+		space.UseSweep1D()
+	})
+}
@@ -0,0 +1,48 @@
+package cp
+
+// UserDataHolder is implemented by every type that carries a UserData field:
+// Body, Shape, Constraint, Arbiter and CollisionHandler.
+type UserDataHolder interface {
+	userData() *interface{}
+}
+
+func (body *Body) userData() *interface{} {
+	return &body.UserData
+}
+
+func (s *Shape) userData() *interface{} {
+	return &s.UserData
+}
+
+func (c *Constraint) userData() *interface{} {
+	return &c.UserData
+}
+
+func (arb *Arbiter) userData() *interface{} {
+	return &arb.UserData
+}
+
+func (handler *CollisionHandler) userData() *interface{} {
+	return &handler.UserData
+}
+
+// SetData stores v as the UserData of obj.
+//
+// It is the typed counterpart of assigning to the UserData field directly, e.g. cp.SetData(shape, player).
+func SetData[T any](obj UserDataHolder, v T) {
+	*obj.userData() = v
+}
+
+// Data returns the UserData of obj as a T.
+//
+// The zero value of T is returned if no data is set or if it holds a value of another type.
+func Data[T any](obj UserDataHolder) T {
+	v, _ := DataOk[T](obj)
+	return v
+}
+
+// DataOk returns the UserData of obj as a T, and whether it actually held a T.
+func DataOk[T any](obj UserDataHolder) (T, bool) {
+	v, ok := (*obj.userData()).(T)
+	return v, ok
+}
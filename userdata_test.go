@@ -0,0 +1,33 @@
+package cp
+
+import "testing"
+
+func TestData(t *testing.T) {
+	type player struct {
+		name string
+	}
+
+	body := NewBody(1, 1)
+	shape := NewCircle(body, 1, Vector{})
+
+	if got := Data[*player](shape); got != nil {
+		t.Errorf("expected nil data, got %v", got)
+	}
+
+	p := &player{"one"}
+	SetData(shape, p)
+	if got := Data[*player](shape); got != p {
+		t.Errorf("got %v want %v", got, p)
+	}
+	if shape.UserData != p {
+		t.Error("SetData should write the UserData field")
+	}
+
+	SetData(body, 42)
+	if _, ok := DataOk[string](body); ok {
+		t.Error("expected type mismatch to report false")
+	}
+	if got := Data[int](body); got != 42 {
+		t.Errorf("got %v want 42", got)
+	}
+}